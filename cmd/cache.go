@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/memory"
+	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/redis"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+)
+
+// newCache собирает кэш заказов по cfg.Cache.Mode. В режиме memory Redis
+// не нужен. Redis прогревается из repo в фоне.
+func newCache(ctx context.Context, cfg *config.Config, repo application.OrdersRepository) (application.Cacher, error) {
+	switch cfg.Cache.Mode {
+	case config.CacheMemory:
+		return memory.NewLRUCache(cfg.Cache.Memory, cfg.Cache.NegativeTTL), nil
+
+	case config.CacheRedis, config.CacheTiered:
+		l2, err := redis.NewRedisCache(ctx, cfg.RedisConfig, cfg.Cache.NegativeTTL, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		if cfg.RedisConfig.WarmUp.Enabled {
+			go warmUp(ctx, l2)
+		}
+		if cfg.Cache.Mode == config.CacheRedis {
+			return l2, nil
+		}
+		return memory.NewTieredCache(memory.NewLRUCache(cfg.Cache.Memory, cfg.Cache.NegativeTTL), l2), nil
+
+	default:
+		return nil, fmt.Errorf("unknown cache mode %q", cfg.Cache.Mode)
+	}
+}
+
+// warmUp прогревает кэш, не задерживая старт: до конца прогрева промахи
+// дочитываются из БД
+func warmUp(ctx context.Context, cache application.Cacher) {
+	logger.Log.Info("Cache warm-up started")
+	if err := cache.WarmUp(ctx); err != nil {
+		if ctx.Err() != nil {
+			logger.Log.Warn("Cache warm-up interrupted: ", err)
+			return
+		}
+		logger.Log.Error("Cache warm-up failed: ", err)
+	}
+}
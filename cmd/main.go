@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
+	"expvar"
+	"flag"
 	"log"
+	"net"
 	netHttp "net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	_ "github.com/Babushkin05/wb-orders-service/docs"
 	"github.com/Babushkin05/wb-orders-service/internal/application"
 	"github.com/Babushkin05/wb-orders-service/internal/config"
 	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/http"
 	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/kafka"
+	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/memory"
 	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/postgres"
-	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/redis"
 	"github.com/Babushkin05/wb-orders-service/pkg/logger"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -34,34 +41,122 @@ func main() {
 	}
 	logger.Log.Info("Logger initialized successfully")
 
-	// Init DB
-	DBconn, err := postgres.NewDB(cfg.DataBase)
-	if err != nil {
-		logger.Log.Fatal("Failed to connect to DB: ", err)
+	// Контекст отменяется по SIGINT/SIGTERM и останавливает фоновые задачи
+	// и запросы к БД и Redis
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Подкоманда migrate up|down [N]|status
+	if args := flag.Args(); len(args) > 0 {
+		if args[0] != "migrate" {
+			logger.Log.Fatal(migrateUsage)
+		}
+		if err := runMigrate(ctx, cfg, args[1:]); err != nil {
+			logger.Log.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+
+	var (
+		db        application.OrdersRepository
+		cache     application.Cacher
+		inboxWake <-chan struct{}
+	)
+
+	switch cfg.Storage {
+	case config.StorageMemory:
+		// Всё в памяти: ни Postgres, ни Redis не нужны
+		db = memory.NewOrdersRepository()
+		cache = memory.NewNopCache()
+		logger.Log.Warn("In-memory storage initialized, data will be lost on restart")
+
+	default:
+		// Init DB
+		DBconn, err := postgres.NewDB(ctx, cfg.DataBase)
+		if err != nil {
+			logger.Log.Fatal("Failed to connect to DB: ", err)
+		}
+		if err := ensureSchema(ctx, DBconn, cfg); err != nil {
+			logger.Log.Fatal("Database schema check failed: ", err)
+		}
+		replicas, err := postgres.NewReplicaSet(cfg.DataBase)
+		if err != nil {
+			logger.Log.Fatal("Failed to open DB replicas: ", err)
+		}
+		defer replicas.Close()
+		replicas.Start(ctx)
+
+		db = postgres.NewOrdersRepository(DBconn, replicas, cfg.DataBase)
+		logger.Log.Info("DB initialized successfully")
+
+		if cfg.DataBase.Partitioning.Enabled {
+			postgres.NewPartitionMaintainer(cfg.DataBase.Partitioning, DBconn).Start(ctx)
+			logger.Log.Info("Partition maintainer started successfully")
+		}
+
+		// Init cache
+		cache, err = newCache(ctx, cfg, db)
+		if err != nil {
+			logger.Log.Fatal("Failed to init cache: ", err)
+		}
+		logger.Log.Infof("Cache (%s) initialized successfully", cfg.Cache.Mode)
+
+		// Init inbox listener
+		inboxListener, err := postgres.NewInboxListener(cfg.DataBase)
+		if err != nil {
+			logger.Log.Fatal("Failed to listen inbox notifications: ", err)
+		}
+		defer inboxListener.Close()
+		inboxWake = inboxListener.Wake()
 	}
-	db := postgres.NewOrdersRepository(DBconn)
-	logger.Log.Info("DB initialized successfully")
 
-	// Init cache
-	redis, err := redis.NewRedisCache(cfg.RedisConfig, DBconn)
+	// Init existence filter. Обёртка репозитория пополняет фильтр при
+	// каждом Store, из HTTP и из Kafka.
+	var filter application.ExistenceFilter
+	if cfg.Cache.Bloom.Enabled {
+		bloomFilter := memory.NewBloomFilter(cfg.Cache.Bloom, db)
+		if cfg.Storage != config.StorageMemory {
+			// Заказы других экземпляров сервиса приходят через NOTIFY.
+			// Слушатель запускается до первой перестройки, чтобы заказы,
+			// сохранённые во время обхода, не потерялись.
+			orderKeysListener, err := postgres.NewOrderKeysListener(cfg.DataBase, bloomFilter.Add, bloomFilter.RequestRebuild)
+			if err != nil {
+				logger.Log.Fatal("Failed to listen order notifications: ", err)
+			}
+			defer orderKeysListener.Close()
+		}
+		bloomFilter.Start(ctx)
+		db = application.NewFilteredRepository(db, bloomFilter)
+		filter = bloomFilter
+		logger.Log.Info("Existence filter started successfully")
+	}
+
+	// Init validation policy
+	policy, err := application.NewValidationPolicy(cfg.Validation)
 	if err != nil {
-		logger.Log.Fatal("Failed to connect to Redis: ", err)
+		logger.Log.Fatal("Invalid validation policy: ", err)
 	}
-	logger.Log.Info("Redis initialized successfully")
 
 	// Init Kafka
-	inboxConsumer := kafka.NewInboxConsumer(cfg.KafkaConfig, db)
-	inboxProcessor := kafka.NewInboxProcessor(db)
+	if cfg.KafkaConfig.Broker != "" {
+		kafka.NewInboxConsumer(cfg.KafkaConfig, db).Start(ctx)
+		logger.Log.Info("Inbox consumer started successfully")
+	} else {
+		logger.Log.Warn("Kafka broker is not configured, inbox consumer disabled")
+	}
 
-	ctx := context.Background()
-	inboxConsumer.Start(ctx)
-	logger.Log.Info("Inbox consumer started successfully")
+	inboxProcessor := kafka.NewInboxProcessor(cfg.InboxConfig, db, cache, policy, inboxWake)
 
 	inboxProcessor.Start(ctx)
 	logger.Log.Info("Inbox processor started successfully")
 
+	if cfg.InboxConfig.Retention.Enabled {
+		kafka.NewInboxJanitor(cfg.InboxConfig.Retention, db).Start(ctx)
+		logger.Log.Info("Inbox janitor started successfully")
+	}
+
 	// Init service
-	ordersService := application.NewOrdersService(redis, db)
+	ordersService := application.NewOrdersService(cache, db, policy, filter)
 
 	// Init HTTP server
 	handler := http.NewHandler(ordersService)
@@ -69,11 +164,31 @@ func main() {
 	http.RegisterRoutes(r, handler)
 	// Swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Metrics
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 
 	// Run server
-	addr := ":" + strconv.Itoa(cfg.Server.Port)
-	logger.Log.Info("Starting server")
-	if err := r.Run(addr); err != nil && err != netHttp.ErrServerClosed {
-		log.Fatalf("server error: %v", err)
+	srv := &netHttp.Server{
+		Addr:    ":" + strconv.Itoa(cfg.Server.Port),
+		Handler: r,
+		// Контексты запросов наследуют ctx, поэтому остановка сервиса
+		// отменяет их обращения к БД и кэшу
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		logger.Log.Info("Starting server")
+		if err := srv.ListenAndServe(); err != nil && err != netHttp.ErrServerClosed {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Log.Info("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Log.Errorf("server shutdown error: %v", err)
 	}
 }
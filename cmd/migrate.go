@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/postgres"
+	"github.com/Babushkin05/wb-orders-service/migrations"
+	"github.com/jmoiron/sqlx"
+)
+
+const migrateUsage = "usage: orders-service [--config=path] migrate up|down [N|all]|status"
+
+// runMigrate выполняет подкоманду migrate: up, down [N] (по умолчанию 1,
+// all — все миграции) или status
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := postgres.NewDB(ctx, cfg.DataBase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := postgres.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migrations, schema version %d\n", n, migrator.Latest())
+
+	case "down":
+		steps := 1
+		if len(args) > 1 && args[1] == "all" {
+			steps = math.MaxInt
+		} else if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		if err := migrator.Down(ctx, steps); err != nil {
+			return err
+		}
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("schema version %d\n", status.Current)
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("current: %d (dirty: %t)\nlatest:  %d\n", status.Current, status.Dirty, status.Latest)
+		for _, name := range status.Pending {
+			fmt.Printf("pending: %s\n", name)
+		}
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
+
+// ensureSchema проверяет, что схема БД совпадает с ожидаемой кодом, и
+// при database.auto_migrate применяет недостающие миграции
+func ensureSchema(ctx context.Context, db *sqlx.DB, cfg *config.Config) error {
+	migrator, err := postgres.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	if cfg.DataBase.AutoMigrate {
+		_, err = migrator.Up(ctx)
+		return err
+	}
+
+	if err := migrator.Check(ctx); err != nil {
+		return fmt.Errorf("%w: run `orders-service migrate up` or set database.auto_migrate", err)
+	}
+	return nil
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"math/rand"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
@@ -85,15 +88,24 @@ func main() {
 		}
 	}
 
-	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers:  []string{broker},
-		Topic:    topic,
-		Balancer: &kafka.LeastBytes{},
-	})
-	defer writer.Close()
+	// GENERATOR_HTTP_URL (например, http://localhost:8080/order) — отправлять
+	// заказы в HTTP API сервиса вместо Kafka, чтобы работать без брокера
+	var send sender
+	if url := os.Getenv("GENERATOR_HTTP_URL"); url != "" {
+		send = httpSender(url)
+		log.Printf("order-generator started: url=%s interval=%ds\n", url, intervalSec)
+	} else {
+		writer := kafka.NewWriter(kafka.WriterConfig{
+			Brokers:  []string{broker},
+			Topic:    topic,
+			Balancer: &kafka.LeastBytes{},
+		})
+		defer writer.Close()
+		send = kafkaSender(writer)
+		log.Printf("order-generator started: broker=%s topic=%s interval=%ds\n", broker, topic, intervalSec)
+	}
 
 	rand.Seed(time.Now().UnixNano())
-	log.Printf("order-generator started: broker=%s topic=%s interval=%ds\n", broker, topic, intervalSec)
 
 	// handle graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -109,18 +121,51 @@ func main() {
 	defer ticker.Stop()
 
 	// send one immediately, then every tick
-	sendOrder(ctx, writer)
+	sendOrder(ctx, send)
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			sendOrder(ctx, writer)
+			sendOrder(ctx, send)
 		}
 	}
 }
 
-func sendOrder(ctx context.Context, writer *kafka.Writer) {
+// sender доставляет сериализованный заказ в сервис
+type sender func(ctx context.Context, orderUID string, data []byte) error
+
+func kafkaSender(writer *kafka.Writer) sender {
+	return func(ctx context.Context, orderUID string, data []byte) error {
+		return writer.WriteMessages(ctx, kafka.Message{
+			Key:   []byte(orderUID),
+			Value: data,
+		})
+	}
+}
+
+func httpSender(url string) sender {
+	return func(ctx context.Context, _ string, data []byte) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+func sendOrder(ctx context.Context, send sender) {
 	order := generateOrder()
 
 	data, err := json.Marshal(order)
@@ -129,16 +174,11 @@ func sendOrder(ctx context.Context, writer *kafka.Writer) {
 		return
 	}
 
-	msg := kafka.Message{
-		Key:   []byte(order.OrderUID),
-		Value: data,
-	}
-
 	// try to write with a timeout context to avoid hanging forever
 	writeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	if err := writer.WriteMessages(writeCtx, msg); err != nil {
+	if err := send(writeCtx, order.OrderUID, data); err != nil {
 		log.Printf("failed to write message: %v\n", err)
 		return
 	}
@@ -153,27 +193,27 @@ func generateOrder() Order {
 
 	items := make([]Item, 1)
 	price := rand.Intn(900) + 100
-	amount := 1
+	sale := rand.Intn(50)
 	items[0] = Item{
 		ChrtID:      rand.Intn(9_999_999),
 		TrackNumber: track,
 		Price:       price,
 		Rid:         uuid.New().String(),
 		Name:        randomProductName(),
-		Sale:        rand.Intn(50),
+		Sale:        sale,
 		Size:        "0",
-		TotalPrice:  price * amount,
+		TotalPrice:  price * (100 - sale) / 100,
 		NmID:        rand.Intn(9_999_999),
 		Brand:       randomBrand(),
 		Status:      200 + rand.Intn(10),
 	}
 
 	payment := Payment{
-		Transaction:  orderUID,
+		Transaction:  uuid.New().String(),
 		RequestID:    "",
 		Currency:     "USD",
 		Provider:     "wbpay",
-		Amount:       items[0].TotalPrice,
+		Amount:       items[0].TotalPrice + 1500,
 		PaymentDT:    time.Now().Unix(),
 		Bank:         randomBank(),
 		DeliveryCost: 1500,
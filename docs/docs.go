@@ -15,6 +15,100 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/order": {
+            "post": {
+                "description": "Проверяет заказ и сохраняет его в БД и кэш",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Сохранить заказ",
+                "parameters": [
+                    {
+                        "description": "Заказ",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Order"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии, которую клиент изменяет, список ETag через запятую или * — заказ должен существовать",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Кто меняет заказ, для истории. Не проверяется сервисом",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заказ уже был сохранён: обновлён или не изменился",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия заказа после записи"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Заказ создан",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия заказа после записи"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный JSON или If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ уже сохранён, а хранилище не обновляет заказы, или заказ в архиве",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заказа не совпала с If-Match или заказа нет",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Заказ не прошёл валидацию",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сохранения",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/order/{order_uid}": {
             "get": {
                 "description": "Возвращает информацию о заказе по его OrderUID",
@@ -32,6 +126,12 @@ const docTemplate = `{
                         "name": "order_uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Вернуть заказ таким, каким он был в этот момент (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -39,6 +139,59 @@ const docTemplate = `{
                         "description": "Успешный ответ",
                         "schema": {
                             "$ref": "#/definitions/dto.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия заказа (без as_of)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный as_of",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения заказа",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{order_uid}/history": {
+            "get": {
+                "description": "Возвращает все версии заказа от старой к новой с источником каждой записи",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "История изменений заказа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order UID",
+                        "name": "order_uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderHistoryResponse"
                         }
                     },
                     "404": {
@@ -46,6 +199,66 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения истории",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/search": {
+            "get": {
+                "description": "Полнотекстовый поиск по названиям и брендам товаров и городу доставки. Все слова запроса должны встретиться в заказе; поддерживаются \"фразы в кавычках\", -исключение и or. Лучшие совпадения первыми.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Поиск заказов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "Vivienne Sabo mascara Haifa",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 20, не больше 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сколько совпадений пропустить",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Пустой запрос или некорректные limit/offset",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка поиска",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -86,6 +299,22 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "payment.amount"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "payment_amount"
+                }
+            }
+        },
         "dto.Item": {
             "type": "object",
             "properties": {
@@ -104,6 +333,12 @@ const docTemplate = `{
                 "price": {
                     "type": "integer"
                 },
+                "price_formatted": {
+                    "description": "Цены в основных единицах валюты, только в ответах",
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "4.53 USD"
+                },
                 "rid": {
                     "type": "string"
                 },
@@ -119,6 +354,11 @@ const docTemplate = `{
                 "total_price": {
                     "type": "integer"
                 },
+                "total_price_formatted": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "3.17 USD"
+                },
                 "track_number": {
                     "type": "string"
                 }
@@ -176,12 +416,105 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.OrderHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "order_uid": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderVersion"
+                    }
+                }
+            }
+        },
+        "dto.OrderSearchHit": {
+            "type": "object",
+            "properties": {
+                "customer_id": {
+                    "type": "string"
+                },
+                "date_created": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "delivery_city": {
+                    "type": "string",
+                    "example": "Haifa"
+                },
+                "delivery_name": {
+                    "type": "string"
+                },
+                "order_uid": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "rank": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "track_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OrderSearchResponse": {
+            "type": "object",
+            "properties": {
+                "hits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderSearchHit"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.OrderVersion": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "type": "string",
+                    "example": "updated"
+                },
+                "changed_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "order": {
+                    "type": "object"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "kafka"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
         "dto.Payment": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "integer"
                 },
+                "amount_formatted": {
+                    "description": "Суммы в основных единицах валюты, только в ответах",
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "18.17 USD"
+                },
                 "bank": {
                     "type": "string"
                 },
@@ -191,12 +524,27 @@ const docTemplate = `{
                 "custom_fee": {
                     "type": "integer"
                 },
+                "custom_fee_formatted": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "0.00 USD"
+                },
                 "delivery_cost": {
                     "type": "integer"
                 },
+                "delivery_cost_formatted": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "15.00 USD"
+                },
                 "goods_total": {
                     "type": "integer"
                 },
+                "goods_total_formatted": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "3.17 USD"
+                },
                 "payment_dt": {
                     "type": "string"
                 },
@@ -210,6 +558,20 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "dto.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldError"
+                    }
+                }
+            }
         }
     }
 }`
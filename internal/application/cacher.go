@@ -1,9 +1,45 @@
 package application
 
-import "github.com/Babushkin05/wb-orders-service/internal/domain/model"
+import (
+	"context"
+	"errors"
 
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+// ErrCacheMiss — заказа нет в кэше, его нужно прочитать из БД
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cacher — кэш заказов. GetOrderFromCache возвращает ErrCacheMiss при
+// промахе и ErrOrderNotFound, если в кэше записано, что заказа нет.
 type Cacher interface {
-	Cache(order *model.Order) error
-	GetOrderFromCache(orderUID string) (model.Order, error)
-	WarmUp() error
+	// Cache записывает заказ, заменяя любую запись о нём, в том числе
+	// запись о том, что заказа нет
+	Cache(ctx context.Context, order *model.Order) error
+	// CacheIfAbsent кладёт прочитанный из БД заказ, только если записи ещё нет:
+	// чтение, начатое до записи заказа, не должно затереть новую версию
+	CacheIfAbsent(ctx context.Context, order *model.Order) error
+	// CacheNotFound запоминает на negative TTL, что заказа нет в БД.
+	// Существующую запись не заменяет, а Cache того же заказа заменяет её.
+	CacheNotFound(ctx context.Context, orderUID string) error
+	// Invalidate удаляет запись о заказе, если она есть
+	Invalidate(ctx context.Context, orderUID string) error
+	// GetOrderFromCache читает заказ из кэша
+	GetOrderFromCache(ctx context.Context, orderUID string) (model.Order, error)
+	// WarmUp заполняет кэш последними заказами после старта
+	WarmUp(ctx context.Context) error
+}
+
+// RefreshCache обновляет кэш после Store: изменённый заказ перезаписывается,
+// а заказ на карантине удаляется из кэша — такие заказы не кэшируются, но
+// старая версия не должна оставаться в кэше. Неизменённый заказ кэш не трогает.
+func RefreshCache(ctx context.Context, cacher Cacher, order *model.Order, result StoreResult) error {
+	switch {
+	case result == StoreUnchanged:
+		return nil
+	case order.ValidationStatus == model.ValidationQuarantined:
+		return cacher.Invalidate(ctx, order.OrderUID.String())
+	default:
+		return cacher.Cache(ctx, order)
+	}
 }
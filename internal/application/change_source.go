@@ -0,0 +1,24 @@
+package application
+
+import (
+	"context"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+type changeSourceKey struct{}
+
+// WithChangeSource помечает контекст источником записи. Репозиторий
+// сохраняет его в истории заказа вместе со снимком.
+func WithChangeSource(ctx context.Context, src model.ChangeSource) context.Context {
+	return context.WithValue(ctx, changeSourceKey{}, src)
+}
+
+// ChangeSourceFrom возвращает источник записи из контекста
+func ChangeSourceFrom(ctx context.Context) model.ChangeSource {
+	src, ok := ctx.Value(changeSourceKey{}).(model.ChangeSource)
+	if !ok || src.Kind == "" {
+		src.Kind = model.ChangeSourceUnknown
+	}
+	return src
+}
@@ -0,0 +1,142 @@
+package contract
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// CacherFactory создаёт кэш для одного подтеста
+type CacherFactory func(t *testing.T) application.Cacher
+
+// TestCacher проверяет реализацию application.Cacher
+func TestCacher(t *testing.T, newCacher CacherFactory) {
+	t.Run("RoundTrip", func(t *testing.T) { testCacheRoundTrip(t, newCacher(t)) })
+	t.Run("Miss", func(t *testing.T) { testCacheMiss(t, newCacher(t)) })
+	t.Run("NotFound", func(t *testing.T) { testCacheNotFound(t, newCacher(t)) })
+	t.Run("Overwrite", func(t *testing.T) { testCacheOverwrite(t, newCacher(t)) })
+	t.Run("FillKeepsNewer", func(t *testing.T) { testCacheFillKeepsNewer(t, newCacher(t)) })
+	t.Run("Invalidate", func(t *testing.T) { testCacheInvalidate(t, newCacher(t)) })
+	t.Run("Concurrent", func(t *testing.T) { testCacheConcurrent(t, newCacher(t)) })
+}
+
+func testCacheRoundTrip(t *testing.T, cache application.Cacher) {
+	ctx := context.Background()
+	order := NewOrder()
+
+	require.NoError(t, cache.Cache(ctx, order))
+
+	got, err := cache.GetOrderFromCache(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	AssertPublicOrderEqual(t, order, &got)
+}
+
+func testCacheMiss(t *testing.T, cache application.Cacher) {
+	_, err := cache.GetOrderFromCache(context.Background(), uuid.NewString())
+	assert.ErrorIs(t, err, application.ErrCacheMiss)
+}
+
+func testCacheNotFound(t *testing.T, cache application.Cacher) {
+	ctx := context.Background()
+	order := NewOrder()
+	uid := order.OrderUID.String()
+
+	require.NoError(t, cache.CacheNotFound(ctx, uid))
+	_, err := cache.GetOrderFromCache(ctx, uid)
+	assert.ErrorIs(t, err, application.ErrOrderNotFound)
+
+	// Сохранённый заказ заменяет запись об отсутствии
+	require.NoError(t, cache.Cache(ctx, order))
+	got, err := cache.GetOrderFromCache(ctx, uid)
+	require.NoError(t, err)
+	AssertPublicOrderEqual(t, order, &got)
+}
+
+func testCacheOverwrite(t *testing.T, cache application.Cacher) {
+	ctx := context.Background()
+	order := NewOrder()
+	require.NoError(t, cache.Cache(ctx, order))
+
+	updated := *order
+	updated.TrackNumber = "WBILMUPDATED"
+	require.NoError(t, cache.Cache(ctx, &updated))
+
+	got, err := cache.GetOrderFromCache(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "WBILMUPDATED", got.TrackNumber)
+}
+
+func testCacheFillKeepsNewer(t *testing.T, cache application.Cacher) {
+	ctx := context.Background()
+	order := NewOrder()
+	uid := order.OrderUID.String()
+
+	stale := *order
+	stale.TrackNumber = "WBILMSTALE"
+
+	// Заполнение после промаха кладёт заказ, если записи нет
+	require.NoError(t, cache.CacheIfAbsent(ctx, &stale))
+	got, err := cache.GetOrderFromCache(ctx, uid)
+	require.NoError(t, err)
+	assert.Equal(t, "WBILMSTALE", got.TrackNumber)
+
+	// но не затирает заказ, записанный после начала чтения
+	require.NoError(t, cache.Cache(ctx, order))
+	require.NoError(t, cache.CacheIfAbsent(ctx, &stale))
+	require.NoError(t, cache.CacheNotFound(ctx, uid))
+	got, err = cache.GetOrderFromCache(ctx, uid)
+	require.NoError(t, err)
+	AssertPublicOrderEqual(t, order, &got)
+}
+
+func testCacheInvalidate(t *testing.T, cache application.Cacher) {
+	ctx := context.Background()
+	order := NewOrder()
+	uid := order.OrderUID.String()
+
+	require.NoError(t, cache.Cache(ctx, order))
+	require.NoError(t, cache.Invalidate(ctx, uid))
+	_, err := cache.GetOrderFromCache(ctx, uid)
+	assert.ErrorIs(t, err, application.ErrCacheMiss)
+
+	// Удаление отсутствующей записи — не ошибка
+	require.NoError(t, cache.Invalidate(ctx, uid))
+}
+
+func testCacheConcurrent(t *testing.T, cache application.Cacher) {
+	ctx := context.Background()
+
+	orders := make([]*model.Order, 20)
+	for i := range orders {
+		orders[i] = NewOrder()
+	}
+
+	var wg sync.WaitGroup
+	for _, order := range orders {
+		wg.Add(2)
+		go func(order *model.Order) {
+			defer wg.Done()
+			assert.NoError(t, cache.Cache(ctx, order))
+		}(order)
+		go func(order *model.Order) {
+			defer wg.Done()
+			// промах или полный заказ, но не частично записанный
+			if got, err := cache.GetOrderFromCache(ctx, order.OrderUID.String()); err == nil {
+				AssertPublicOrderEqual(t, order, &got)
+			}
+		}(order)
+	}
+	wg.Wait()
+
+	for _, order := range orders {
+		got, err := cache.GetOrderFromCache(ctx, order.OrderUID.String())
+		require.NoError(t, err)
+		AssertPublicOrderEqual(t, order, &got)
+	}
+}
@@ -0,0 +1,145 @@
+// Package contract содержит общие тесты, которые должна проходить любая
+// реализация application.OrdersRepository и application.Cacher.
+package contract
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// searchToken возвращает случайное слово из букв: поисковый анализатор
+// разбивает слова с цифрами иначе, чем простые
+func searchToken() string {
+	return "zq" + strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return 'g' + (r - '0')
+		}
+		return r
+	}, strings.ReplaceAll(uuid.NewString(), "-", "")[:12])
+}
+
+// NewOrder возвращает валидный заказ, в котором заполнены все поля.
+// Время с микросекундами — чтобы проверить, что бэкенд их не теряет.
+func NewOrder() *model.Order {
+	uid := uuid.New()
+	created := time.Date(2026, 3, 14, 15, 9, 26, 535897000, time.UTC)
+
+	return &model.Order{
+		OrderUID:          uid,
+		TrackNumber:       "WBILMTESTTRACK",
+		Entry:             "WBIL",
+		Locale:            "en",
+		InternalSignature: "sig",
+		CustomerID:        "test",
+		DeliveryService:   "meest",
+		ShardKey:          "9",
+		SmID:              99,
+		DateCreated:       created,
+		OofShard:          "1",
+		Delivery: model.Delivery{
+			OrderUID: uid,
+			Name:     "Test Testov",
+			Phone:    "+9720000000",
+			Zip:      "2639809",
+			City:     "Kiryat Mozkin",
+			Address:  "Ploshad Mira 15",
+			Region:   "Kraiot",
+			Email:    "test@gmail.com",
+		},
+		Payment: model.Payment{
+			OrderUID:     uid,
+			Transaction:  uuid.New(),
+			RequestID:    "req",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       model.NewMoney(1817, "USD"),
+			PaymentDT:    created.Add(time.Minute),
+			Bank:         "alpha",
+			DeliveryCost: model.NewMoney(1500, "USD"),
+			GoodsTotal:   model.NewMoney(317, "USD"),
+			CustomFee:    model.NewMoney(0, "USD"),
+		},
+		Items: []model.Item{
+			{
+				OrderUID:    uid,
+				ChrtID:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       model.NewMoney(453, "USD"),
+				Rid:         uuid.NewString(),
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  model.NewMoney(317, "USD"),
+				NmID:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+			{
+				OrderUID:    uid,
+				ChrtID:      9934931,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       model.NewMoney(0, "USD"),
+				Rid:         uuid.NewString(),
+				Name:        "Sample",
+				Sale:        0,
+				Size:        "0",
+				TotalPrice:  model.NewMoney(0, "USD"),
+				NmID:        2389213,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+		ValidationStatus: model.ValidationWarned,
+		ValidationWarnings: model.FieldErrors{
+			{Field: "delivery.zip", Rule: model.RuleRequired, Message: "test warning"},
+		},
+	}
+}
+
+// AssertOrderEqual сравнивает все поля заказа, кроме суррогатных id товаров.
+// Время сравнивается как момент, без учёта таймзоны.
+func AssertOrderEqual(t *testing.T, want, got *model.Order) {
+	t.Helper()
+
+	assert.Equal(t, want.OrderUID, got.OrderUID, "order_uid")
+	assert.True(t, want.DateCreated.Equal(got.DateCreated), "date_created: want %s, got %s", want.DateCreated, got.DateCreated)
+	assert.True(t, want.Payment.PaymentDT.Equal(got.Payment.PaymentDT), "payment_dt: want %s, got %s", want.Payment.PaymentDT, got.Payment.PaymentDT)
+
+	w, g := normalize(want), normalize(got)
+	assert.Equal(t, w, g)
+}
+
+func normalize(order *model.Order) model.Order {
+	o := *order
+	o.DateCreated = time.Time{}
+	o.Payment.PaymentDT = time.Time{}
+	// Версию проверяет отдельный тест
+	o.Version = 0
+	o.Items = make([]model.Item, len(order.Items))
+	for i, it := range order.Items {
+		it.ID = 0
+		o.Items[i] = it
+	}
+	if len(o.ValidationWarnings) == 0 {
+		o.ValidationWarnings = nil
+	}
+	return o
+}
+
+// AssertPublicOrderEqual сравнивает заказы так, как их видит клиент API.
+// Кэши хранят только публичное представление заказа.
+func AssertPublicOrderEqual(t *testing.T, want, got *model.Order) {
+	t.Helper()
+
+	wantJSON, err := model.MarshalOrder(want)
+	assert.NoError(t, err)
+	gotJSON, err := model.MarshalOrder(got)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, string(wantJSON), string(gotJSON))
+}
@@ -0,0 +1,481 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RepositoryFactory создаёт репозиторий для одного подтеста. Store должен
+// работать в режиме upsert.
+type RepositoryFactory func(t *testing.T) application.OrdersRepository
+
+// TestOrdersRepository проверяет реализацию application.OrdersRepository.
+// Тесты не рассчитывают на пустое хранилище, поэтому их можно запускать
+// на общей БД.
+func TestOrdersRepository(t *testing.T, newRepo RepositoryFactory) {
+	t.Run("RoundTrip", func(t *testing.T) { testRoundTrip(t, newRepo(t)) })
+	t.Run("LargeAmounts", func(t *testing.T) { testLargeAmounts(t, newRepo(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newRepo(t)) })
+	t.Run("Duplicate", func(t *testing.T) { testDuplicate(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newRepo(t)) })
+	t.Run("Version", func(t *testing.T) { testVersion(t, newRepo(t)) })
+	t.Run("History", func(t *testing.T) { testHistory(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newRepo(t)) })
+	t.Run("ListOrders", func(t *testing.T) { testListOrders(t, newRepo(t)) })
+	t.Run("ConcurrentStore", func(t *testing.T) { testConcurrentStore(t, newRepo(t)) })
+	t.Run("InboxOrdering", func(t *testing.T) { testInboxOrdering(t, newRepo(t)) })
+	t.Run("InboxDeadLetter", func(t *testing.T) { testInboxDeadLetter(t, newRepo(t)) })
+	t.Run("InboxPurge", func(t *testing.T) { testInboxPurge(t, newRepo(t)) })
+}
+
+func testRoundTrip(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	order := NewOrder()
+
+	result, err := repo.Store(ctx, order)
+	require.NoError(t, err)
+	assert.Equal(t, application.StoreCreated, result)
+
+	got, err := repo.Get(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	AssertOrderEqual(t, order, &got)
+}
+
+// testLargeAmounts проверяет суммы, не помещающиеся в int32
+func testLargeAmounts(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	order := NewOrder()
+
+	const price = int64(5_000_000_000)
+	order.Items = order.Items[:1]
+	order.Items[0].Price.Minor = price
+	order.Items[0].Sale = 0
+	order.Items[0].TotalPrice.Minor = price
+	order.Payment.GoodsTotal.Minor = price
+	order.Payment.Amount.Minor = price + order.Payment.DeliveryCost.Minor + order.Payment.CustomFee.Minor
+	require.NoError(t, order.Validate())
+
+	_, err := repo.Store(ctx, order)
+	require.NoError(t, err)
+
+	got, err := repo.Get(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	AssertOrderEqual(t, order, &got)
+}
+
+func testNotFound(t *testing.T, repo application.OrdersRepository) {
+	_, err := repo.Get(context.Background(), uuid.NewString())
+	assert.ErrorIs(t, err, application.ErrOrderNotFound)
+}
+
+func testDuplicate(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	order := NewOrder()
+
+	_, err := repo.Store(ctx, order)
+	require.NoError(t, err)
+
+	again := *order
+	result, err := repo.Store(ctx, &again)
+	require.NoError(t, err)
+	assert.Equal(t, application.StoreUnchanged, result)
+}
+
+func testUpdate(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	order := NewOrder()
+
+	_, err := repo.Store(ctx, order)
+	require.NoError(t, err)
+
+	updated := *order
+	updated.TrackNumber = "WBILMUPDATED"
+	updated.Delivery.City = "Haifa"
+	updated.Payment.Bank = "bankB"
+	updated.Items = []model.Item{order.Items[0]}
+	updated.Items[0].Status = 300
+
+	result, err := repo.Store(ctx, &updated)
+	require.NoError(t, err)
+	assert.Equal(t, application.StoreUpdated, result)
+
+	got, err := repo.Get(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	AssertOrderEqual(t, &updated, &got)
+}
+
+func testVersion(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	order := NewOrder()
+
+	_, err := repo.Store(ctx, order)
+	require.NoError(t, err)
+	assert.Equal(t, 1, order.Version)
+
+	// Запись без изменений версию не меняет
+	again := *order
+	again.Version = 0
+	_, err = repo.Store(ctx, &again)
+	require.NoError(t, err)
+	assert.Equal(t, 1, again.Version)
+
+	first := *order
+	first.TrackNumber = "WBILMFIRST"
+	result, err := repo.Store(ctx, &first)
+	require.NoError(t, err)
+	assert.Equal(t, application.StoreUpdated, result)
+	assert.Equal(t, 2, first.Version)
+
+	// Второй клиент читал версию 1 и проигрывает
+	second := *order
+	second.TrackNumber = "WBILMSECOND"
+	_, err = repo.Store(ctx, &second)
+	assert.ErrorIs(t, err, application.ErrVersionConflict)
+
+	got, err := repo.Get(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Version)
+	assert.Equal(t, "WBILMFIRST", got.TrackNumber)
+
+	// Ожидание версии несуществующего заказа — тоже конфликт
+	missing := NewOrder()
+	missing.Version = 1
+	_, err = repo.Store(ctx, missing)
+	assert.ErrorIs(t, err, application.ErrVersionConflict)
+	_, err = repo.Get(ctx, missing.OrderUID.String())
+	assert.ErrorIs(t, err, application.ErrOrderNotFound)
+
+	// AnyVersion требует, чтобы заказ существовал, версия не важна
+	missing.Version = model.AnyVersion
+	_, err = repo.Store(ctx, missing)
+	assert.ErrorIs(t, err, application.ErrVersionConflict)
+
+	anyVersion := *order
+	anyVersion.Version = model.AnyVersion
+	anyVersion.TrackNumber = "WBILMANY"
+	result, err = repo.Store(ctx, &anyVersion)
+	require.NoError(t, err)
+	assert.Equal(t, application.StoreUpdated, result)
+	assert.Equal(t, 3, anyVersion.Version)
+}
+
+func testHistory(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	order := NewOrder()
+
+	kafkaSrc := model.ChangeSource{Kind: model.ChangeSourceKafka, ID: "msg-1"}
+	_, err := repo.Store(application.WithChangeSource(ctx, kafkaSrc), order)
+	require.NoError(t, err)
+
+	// Запись без изменений версию не добавляет
+	again := *order
+	_, err = repo.Store(ctx, &again)
+	require.NoError(t, err)
+
+	updated := *order
+	updated.TrackNumber = "WBILMUPDATED"
+	httpSrc := model.ChangeSource{Kind: model.ChangeSourceHTTP, ID: "alice"}
+	_, err = repo.Store(application.WithChangeSource(ctx, httpSrc), &updated)
+	require.NoError(t, err)
+
+	versions, err := repo.OrderHistory(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, string(application.StoreCreated), versions[0].Change)
+	assert.Equal(t, kafkaSrc, versions[0].Source)
+	AssertPublicOrderEqual(t, order, &versions[0].Order)
+
+	assert.Equal(t, 2, versions[1].Version)
+	assert.Equal(t, string(application.StoreUpdated), versions[1].Change)
+	assert.Equal(t, httpSrc, versions[1].Source)
+	AssertPublicOrderEqual(t, &updated, &versions[1].Order)
+
+	// as_of берёт последнюю версию не позже момента
+	got, err := repo.GetAsOf(ctx, order.OrderUID.String(), versions[0].ChangedAt)
+	require.NoError(t, err)
+	if versions[1].ChangedAt.After(versions[0].ChangedAt) {
+		AssertPublicOrderEqual(t, order, &got)
+	}
+
+	got, err = repo.GetAsOf(ctx, order.OrderUID.String(), versions[1].ChangedAt)
+	require.NoError(t, err)
+	AssertPublicOrderEqual(t, &updated, &got)
+
+	_, err = repo.GetAsOf(ctx, order.OrderUID.String(), versions[0].ChangedAt.Add(-time.Second))
+	assert.ErrorIs(t, err, application.ErrOrderNotFound)
+
+	_, err = repo.OrderHistory(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, application.ErrOrderNotFound)
+}
+
+func testSearch(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	// Уникальное слово отделяет заказы теста от остальных в общей БД
+	token := searchToken()
+
+	byItems := NewOrder()
+	byItems.Items[0].Name = "Lipsticks " + token
+	byItems.Items[0].Brand = "Rimmel " + token
+	_, err := repo.Store(ctx, byItems)
+	require.NoError(t, err)
+
+	byCity := NewOrder()
+	byCity.Delivery.City = "Haifa " + token
+	_, err = repo.Store(ctx, byCity)
+	require.NoError(t, err)
+
+	result, err := repo.SearchOrders(ctx, model.OrderSearch{Query: token, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	require.Len(t, result.Hits, 2)
+	// Совпадение в товарах весит больше, чем в городе
+	assert.Equal(t, byItems.OrderUID, result.Hits[0].OrderUID)
+	assert.Equal(t, byCity.OrderUID, result.Hits[1].OrderUID)
+	assert.Greater(t, result.Hits[0].Rank, result.Hits[1].Rank)
+	assert.Equal(t, byItems.TrackNumber, result.Hits[0].TrackNumber)
+	assert.Equal(t, byCity.Delivery.City, result.Hits[1].DeliveryCity)
+
+	result, err = repo.SearchOrders(ctx, model.OrderSearch{Query: "lipstick " + token, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, byItems.OrderUID, result.Hits[0].OrderUID)
+
+	result, err = repo.SearchOrders(ctx, model.OrderSearch{Query: token + " Haifa", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, byCity.OrderUID, result.Hits[0].OrderUID)
+
+	result, err = repo.SearchOrders(ctx, model.OrderSearch{Query: token, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, byCity.OrderUID, result.Hits[0].OrderUID)
+
+	// Поиск видит изменения товаров
+	byItems.Items[0].Name = "Mascaras"
+	byItems.Items[0].Brand = "Vivienne Sabo"
+	_, err = repo.Store(ctx, byItems)
+	require.NoError(t, err)
+
+	result, err = repo.SearchOrders(ctx, model.OrderSearch{Query: token, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+}
+
+func testListOrders(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+	// Случайный момент в будущем, чтобы не смешиваться с заказами других тестов
+	base := time.Date(2090, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rand.Int63n(int64(24 * 365 * time.Hour))))
+
+	newest, tieA, tieB, old := NewOrder(), NewOrder(), NewOrder(), NewOrder()
+	newest.DateCreated = base.Add(3 * time.Hour)
+	tieA.DateCreated = base.Add(2 * time.Hour)
+	tieB.DateCreated = base.Add(2 * time.Hour)
+	old.DateCreated = base.Add(-time.Hour)
+	for _, o := range []*model.Order{old, tieA, newest, tieB} {
+		_, err := repo.Store(ctx, o)
+		require.NoError(t, err)
+	}
+	if tieA.OrderUID.String() < tieB.OrderUID.String() {
+		tieA, tieB = tieB, tieA
+	}
+	want := []*model.Order{newest, tieA, tieB}
+
+	ours := make(map[uuid.UUID]bool)
+	for _, o := range append(want, old) {
+		ours[o.OrderUID] = true
+	}
+
+	// Обход страницами по 2 от base
+	var got []model.Order
+	listing := model.OrderListing{Since: base, Limit: 2}
+	for {
+		page, err := repo.ListOrders(ctx, listing)
+		require.NoError(t, err)
+		for _, o := range page {
+			if ours[o.OrderUID] {
+				got = append(got, o)
+			}
+		}
+		if len(page) < listing.Limit {
+			break
+		}
+		listing.After = page[len(page)-1].Cursor()
+	}
+
+	require.Len(t, got, len(want))
+	for i := range want {
+		AssertOrderEqual(t, want[i], &got[i])
+	}
+}
+
+func testConcurrentStore(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+
+	orders := make([]*model.Order, 10)
+	for i := range orders {
+		orders[i] = NewOrder()
+	}
+
+	// Каждый заказ сохраняют несколько горутин сразу: создать его должна ровно одна
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created = make(map[uuid.UUID]int)
+	)
+	for _, order := range orders {
+		for j := 0; j < 3; j++ {
+			wg.Add(1)
+			go func(order model.Order) {
+				defer wg.Done()
+				result, err := repo.Store(ctx, &order)
+				assert.NoError(t, err)
+				if result == application.StoreCreated {
+					mu.Lock()
+					created[order.OrderUID]++
+					mu.Unlock()
+				}
+				_, err = repo.Get(ctx, order.OrderUID.String())
+				assert.NoError(t, err)
+			}(*order)
+		}
+	}
+	wg.Wait()
+
+	for _, order := range orders {
+		assert.Equal(t, 1, created[order.OrderUID], "order %s", order.OrderUID)
+		got, err := repo.Get(ctx, order.OrderUID.String())
+		require.NoError(t, err)
+		AssertOrderEqual(t, order, &got)
+	}
+}
+
+func testInboxOrdering(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+
+	prefix := uuid.NewString()
+	// все сообщения об одном заказе: сохраняются все, ключ не уникален
+	key := uuid.NewString()
+	ids := make([]string, 5)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s-%d", prefix, i)
+		msg := model.InboxMessage{ID: ids[i], Key: key, Topic: "contract", Payload: fmt.Sprint(i)}
+		require.NoError(t, repo.SaveInboxMessage(ctx, msg))
+	}
+	// повторное сообщение с тем же id игнорируется
+	dup := model.InboxMessage{ID: ids[0], Key: key, Topic: "contract", Payload: "duplicate"}
+	require.NoError(t, repo.SaveInboxMessage(ctx, dup))
+
+	backlog, err := repo.InboxBacklog(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, backlog.Pending, len(ids))
+
+	msgs := fetchOwn(t, repo, prefix)
+	require.Len(t, msgs, len(ids))
+	for i, msg := range msgs {
+		assert.Equal(t, ids[i], msg.ID)
+		assert.Equal(t, key, msg.Key)
+		assert.Equal(t, "contract", msg.Topic)
+		assert.Equal(t, fmt.Sprint(i), msg.Payload)
+	}
+
+	require.NoError(t, repo.MarkInboxMessageProcessed(ctx, ids[0]))
+	require.NoError(t, repo.MarkInboxMessageProcessed(ctx, ids[2]))
+
+	msgs = fetchOwn(t, repo, prefix)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, []string{ids[1], ids[3], ids[4]}, []string{msgs[0].ID, msgs[1].ID, msgs[2].ID})
+}
+
+func testInboxDeadLetter(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+
+	prefix := uuid.NewString()
+	id := prefix + "-bad"
+	require.NoError(t, repo.SaveInboxMessage(ctx, model.InboxMessage{ID: id, Topic: "contract", Payload: "{"}))
+	require.NoError(t, repo.MarkInboxMessageDeadLettered(ctx, id))
+
+	assert.Empty(t, fetchOwn(t, repo, prefix))
+
+	// свежие сообщения не попадают под очистку
+	n, err := repo.PurgeInboxMessages(ctx, model.InboxPurge{DeadLettered: true, OlderThan: 100 * 365 * 24 * time.Hour, Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func testInboxPurge(t *testing.T, repo application.OrdersRepository) {
+	ctx := context.Background()
+
+	prefix := uuid.NewString()
+	msgs := make([]model.InboxMessage, 3)
+	for i := range msgs {
+		msgs[i] = model.InboxMessage{ID: fmt.Sprintf("%s-%d", prefix, i), Topic: "contract", Payload: fmt.Sprint(i)}
+		require.NoError(t, repo.SaveInboxMessage(ctx, msgs[i]))
+	}
+	processed, deadLettered, pending := msgs[0], msgs[1], msgs[2]
+	require.NoError(t, repo.MarkInboxMessageProcessed(ctx, processed.ID))
+	require.NoError(t, repo.MarkInboxMessageDeadLettered(ctx, deadLettered.ID))
+	time.Sleep(10 * time.Millisecond)
+
+	// Удалённое сообщение можно сохранить заново, а оставшееся в inbox
+	// повтор игнорирует
+	resave := func() []string {
+		for _, msg := range msgs {
+			require.NoError(t, repo.SaveInboxMessage(ctx, msg))
+		}
+		var ids []string
+		for _, msg := range fetchOwn(t, repo, prefix) {
+			ids = append(ids, msg.ID)
+		}
+		return ids
+	}
+
+	purgeAll(t, repo, model.InboxPurge{OlderThan: time.Millisecond, Archive: true})
+	assert.Equal(t, []string{pending.ID, processed.ID}, resave())
+
+	purgeAll(t, repo, model.InboxPurge{DeadLettered: true, OlderThan: time.Millisecond, Archive: true})
+	assert.Equal(t, []string{pending.ID, processed.ID, deadLettered.ID}, resave())
+}
+
+// purgeAll очищает inbox пачками, пока есть что удалять
+func purgeAll(t *testing.T, repo application.OrdersRepository, opts model.InboxPurge) {
+	t.Helper()
+
+	opts.Limit = 1000
+	for {
+		n, err := repo.PurgeInboxMessages(context.Background(), opts)
+		require.NoError(t, err)
+		require.LessOrEqual(t, n, opts.Limit)
+		if n < opts.Limit {
+			return
+		}
+	}
+}
+
+// fetchOwn выбирает необработанные сообщения и оставляет только созданные тестом
+func fetchOwn(t *testing.T, repo application.OrdersRepository, prefix string) []model.InboxMessage {
+	t.Helper()
+
+	msgs, err := repo.FetchUnprocessedInboxMessages(context.Background(), 10000)
+	require.NoError(t, err)
+
+	var own []model.InboxMessage
+	for _, msg := range msgs {
+		if len(msg.ID) > len(prefix) && msg.ID[:len(prefix)] == prefix {
+			own = append(own, msg)
+		}
+	}
+	return own
+}
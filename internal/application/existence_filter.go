@@ -0,0 +1,34 @@
+package application
+
+import (
+	"context"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+// ExistenceFilter отвечает, мог ли заказ быть сохранён. false — заказа
+// точно нет, и ни кэш, ни БД можно не спрашивать.
+type ExistenceFilter interface {
+	Add(orderUID string)
+	MayExist(orderUID string) bool
+}
+
+// filteredRepository добавляет в фильтр каждый сохранённый заказ
+type filteredRepository struct {
+	OrdersRepository
+	filter ExistenceFilter
+}
+
+// NewFilteredRepository оборачивает repo так, чтобы заказы из любых
+// источников записи (HTTP, Kafka) попадали в filter
+func NewFilteredRepository(repo OrdersRepository, filter ExistenceFilter) OrdersRepository {
+	return &filteredRepository{OrdersRepository: repo, filter: filter}
+}
+
+func (r *filteredRepository) Store(ctx context.Context, order *model.Order) (StoreResult, error) {
+	result, err := r.OrdersRepository.Store(ctx, order)
+	if err == nil {
+		r.filter.Add(order.OrderUID.String())
+	}
+	return result, err
+}
@@ -2,14 +2,64 @@ package application
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
 )
 
+// ErrOrderNotFound — заказа с таким order_uid нет
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrVersionConflict — версия заказа в хранилище не совпала с ожидаемой
+var ErrVersionConflict = errors.New("order version conflict")
+
+// ErrOrderArchived — заказ лежит в архиве и не изменяется
+var ErrOrderArchived = errors.New("order is archived")
+
+// ErrOrderExists — заказ уже сохранён, а хранилище не обновляет заказы
+var ErrOrderExists = errors.New("order already exists")
+
+// ErrOrderRejected — хранилище отклонило данные заказа (значение вне
+// диапазона столбца, нарушение ограничения). Повтор той же записи не поможет.
+var ErrOrderRejected = errors.New("order rejected by storage")
+
+// StoreResult — что сделал Store с заказом
+type StoreResult string
+
+const (
+	StoreCreated   StoreResult = "created"
+	StoreUpdated   StoreResult = "updated"
+	StoreUnchanged StoreResult = "unchanged"
+)
+
 type OrdersRepository interface {
-	Get(orderUID string) (model.Order, error)
-	Store(model *model.Order) error
-	SaveInboxMessage(ctx context.Context, messageID, topic, payload string) error
+	Get(ctx context.Context, orderUID string) (model.Order, error)
+	// Store сохраняет заказ. Если order.Version не ноль, заказ обновляется
+	// только при совпадении версии, иначе — ErrVersionConflict. После
+	// успешной записи order.Version содержит новую версию.
+	Store(ctx context.Context, model *model.Order) (StoreResult, error)
+	// GetAsOf возвращает заказ таким, каким он был в момент at
+	GetAsOf(ctx context.Context, orderUID string, at time.Time) (model.Order, error)
+	// OrderHistory возвращает версии заказа от старой к новой
+	OrderHistory(ctx context.Context, orderUID string) ([]model.OrderVersion, error)
+	// SearchOrders ищет заказы по товарам и городу доставки, лучшие
+	// совпадения первыми
+	SearchOrders(ctx context.Context, search model.OrderSearch) (model.OrderSearchResult, error)
+	// ListOrders возвращает заказы целиком от новых к старым, для прогрева
+	// кэша. Архивные заказы не входят.
+	ListOrders(ctx context.Context, listing model.OrderListing) ([]model.Order, error)
+	// ForEachOrderUID вызывает fn для order_uid каждого сохранённого заказа,
+	// включая архивные. Ошибка fn прерывает обход.
+	ForEachOrderUID(ctx context.Context, fn func(orderUID string) error) error
+	// SaveInboxMessage сохраняет сообщение; повтор с тем же ID игнорируется
+	SaveInboxMessage(ctx context.Context, msg model.InboxMessage) error
 	FetchUnprocessedInboxMessages(ctx context.Context, limit int) ([]model.InboxMessage, error)
 	MarkInboxMessageProcessed(ctx context.Context, messageID string) error
+	MarkInboxMessageDeadLettered(ctx context.Context, messageID string) error
+	// RecordInboxMessageFailure запоминает ошибку обработки сообщения и
+	// возвращает число неудачных попыток вместе с этой
+	RecordInboxMessageFailure(ctx context.Context, messageID, reason string) (int, error)
+	InboxBacklog(ctx context.Context) (model.InboxBacklog, error)
+	PurgeInboxMessages(ctx context.Context, opts model.InboxPurge) (int, error)
 }
@@ -1,55 +1,185 @@
 package application
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+	"golang.org/x/sync/singleflight"
 )
 
 type OrdersService interface {
-	GetOrder(orderUID string) (model.Order, error)
-	SaveOrder(order *model.Order) error
+	GetOrder(ctx context.Context, orderUID string) (model.Order, error)
+	SaveOrder(ctx context.Context, order *model.Order) (StoreResult, error)
+	GetOrderAsOf(ctx context.Context, orderUID string, at time.Time) (model.Order, error)
+	GetOrderHistory(ctx context.Context, orderUID string) ([]model.OrderVersion, error)
+	SearchOrders(ctx context.Context, search model.OrderSearch) (model.OrderSearchResult, error)
 }
 
+// Размер страницы поиска заказов
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// ErrEmptySearchQuery — в запросе поиска нет ни одного слова
+var ErrEmptySearchQuery = errors.New("search query is empty")
+
+// HTTPTopic — "топик" для политики валидации заказов, пришедших через
+// сервис (HTTP API), а не из Kafka
+const HTTPTopic = "http"
+
 type ordersService struct {
 	cacher           Cacher
 	ordersRepository OrdersRepository
+	policy           *ValidationPolicy
+	filter           ExistenceFilter
+	// loads схлопывает одновременные чтения одного заказа из БД при промахе
+	loads singleflight.Group
 }
 
 var _ OrdersService = &ordersService{}
 
-func NewOrdersService(casher Cacher, ordersRepository OrdersRepository) OrdersService {
+// NewOrdersService создаёт сервис заказов. filter может быть nil — тогда
+// каждый промах кэша проверяется в БД.
+func NewOrdersService(casher Cacher, ordersRepository OrdersRepository, policy *ValidationPolicy, filter ExistenceFilter) OrdersService {
 	return &ordersService{
 		cacher:           casher,
 		ordersRepository: ordersRepository,
+		policy:           policy,
+		filter:           filter,
 	}
 }
 
-func (s *ordersService) GetOrder(orderUID string) (model.Order, error) {
+func (s *ordersService) GetOrder(ctx context.Context, orderUID string) (model.Order, error) {
 	if orderUID == "" {
 		return model.Order{}, errors.New("orderUID is empty")
 	}
 
-	order, err := s.cacher.GetOrderFromCache(orderUID)
-	if err != nil {
-		order, err = s.ordersRepository.Get(orderUID)
+	if s.filter != nil && !s.filter.MayExist(orderUID) {
+		return model.Order{}, ErrOrderNotFound
+	}
+
+	order, err := s.cacher.GetOrderFromCache(ctx, orderUID)
+	switch {
+	case err == nil:
+		return order, nil
+	case errors.Is(err, ErrOrderNotFound):
+		// Недавно проверяли: заказа нет
+		return model.Order{}, err
+	}
+
+	return s.loadOrder(ctx, orderUID)
+}
+
+// loadOrder читает заказ после промаха кэша и кладёт его в кэш, а
+// отсутствующий заказ — в кэш как отсутствующий. Чтение может уйти на
+// реплику: заказы, записанные за окно read-your-writes, репозиторий читает
+// с primary, поэтому устаревшая копия в кэш не попадает. Запись в кэш не
+// заменяет уже лежащую там: её мог положить SaveOrder, пока шло чтение.
+// Одновременные промахи по одному orderUID ждут одного чтения. Чтение не
+// привязано к отмене контекста первого запроса: остальные ждут его результат.
+func (s *ordersService) loadOrder(ctx context.Context, orderUID string) (model.Order, error) {
+	ch := s.loads.DoChan(orderUID, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+
+		order, err := s.ordersRepository.Get(ctx, orderUID)
+		if errors.Is(err, ErrOrderNotFound) {
+			if err := s.cacher.CacheNotFound(ctx, orderUID); err != nil {
+				logger.Log.Warnf("GetOrder: failed to cache missing order %s: %v", orderUID, err)
+			}
+			return model.Order{}, err
+		}
 		if err != nil {
 			return model.Order{}, err
 		}
+
+		// Заказы на карантине в кэш не попадают, как и при записи.
+		// Ошибка кэша чтению не мешает: заказ уже прочитан.
+		if order.ValidationStatus != model.ValidationQuarantined {
+			if err := s.cacher.CacheIfAbsent(ctx, &order); err != nil {
+				logger.Log.Warnf("GetOrder: failed to cache order %s: %v", orderUID, err)
+			}
+		}
+		return order, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return model.Order{}, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return model.Order{}, res.Err
+		}
+		order := res.Val.(model.Order)
+		if res.Shared {
+			// Срезы общего результата не должны делить разные вызывающие
+			order.Items = append([]model.Item(nil), order.Items...)
+			order.ValidationWarnings = append(model.FieldErrors(nil), order.ValidationWarnings...)
+		}
+		return order, nil
 	}
+}
 
-	return order, nil
+// GetOrderAsOf читает историю мимо кэша: кэш хранит только текущую версию
+func (s *ordersService) GetOrderAsOf(ctx context.Context, orderUID string, at time.Time) (model.Order, error) {
+	if orderUID == "" {
+		return model.Order{}, errors.New("orderUID is empty")
+	}
+
+	return s.ordersRepository.GetAsOf(ctx, orderUID, at)
+}
+
+func (s *ordersService) GetOrderHistory(ctx context.Context, orderUID string) ([]model.OrderVersion, error) {
+	if orderUID == "" {
+		return nil, errors.New("orderUID is empty")
+	}
+
+	return s.ordersRepository.OrderHistory(ctx, orderUID)
 }
 
-func (s *ordersService) SaveOrder(order *model.Order) error {
+func (s *ordersService) SaveOrder(ctx context.Context, order *model.Order) (StoreResult, error) {
 	if order == nil {
-		return errors.New("order is nil")
+		return "", errors.New("order is nil")
 	}
 
-	err := s.cacher.Cache(order)
+	if err := s.policy.Apply(order, HTTPTopic); err != nil {
+		return "", err
+	}
+
+	result, err := s.ordersRepository.Store(ctx, order)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	// Заказ уже сохранён: из-за сбоя кэша клиент не должен считать запись
+	// неудачной и повторять её
+	if err := RefreshCache(ctx, s.cacher, order, result); err != nil {
+		logger.Log.Warnf("SaveOrder: failed to refresh cached order %s: %v", order.OrderUID, err)
+	}
+
+	return result, nil
+}
+
+// SearchOrders ищет мимо кэша: кэш не индексирует содержимое заказов.
+// Limit вне 1..MaxSearchLimit заменяется значением по умолчанию или пределом.
+func (s *ordersService) SearchOrders(ctx context.Context, search model.OrderSearch) (model.OrderSearchResult, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Query == "" {
+		return model.OrderSearchResult{}, ErrEmptySearchQuery
+	}
+	switch {
+	case search.Limit <= 0:
+		search.Limit = DefaultSearchLimit
+	case search.Limit > MaxSearchLimit:
+		search.Limit = MaxSearchLimit
+	}
+	if search.Offset < 0 {
+		search.Offset = 0
 	}
 
-	return s.ordersRepository.Store(order)
+	return s.ordersRepository.SearchOrders(ctx, search)
 }
@@ -3,7 +3,9 @@ package application
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
 	"github.com/google/uuid"
@@ -14,30 +16,60 @@ import (
 // ----- Моки -----
 type mockCacher struct{ mock.Mock }
 
-func (m *mockCacher) Cache(order *model.Order) error {
+func (m *mockCacher) Cache(_ context.Context, order *model.Order) error {
 	args := m.Called(order)
 	return args.Error(0)
 }
-func (m *mockCacher) GetOrderFromCache(orderUID string) (model.Order, error) {
+func (m *mockCacher) CacheIfAbsent(_ context.Context, order *model.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+func (m *mockCacher) CacheNotFound(_ context.Context, orderUID string) error {
+	args := m.Called(orderUID)
+	return args.Error(0)
+}
+func (m *mockCacher) Invalidate(_ context.Context, orderUID string) error {
+	args := m.Called(orderUID)
+	return args.Error(0)
+}
+func (m *mockCacher) GetOrderFromCache(_ context.Context, orderUID string) (model.Order, error) {
 	args := m.Called(orderUID)
 	return args.Get(0).(model.Order), args.Error(1)
 }
-func (m *mockCacher) WarmUp() error {
+func (m *mockCacher) WarmUp(_ context.Context) error {
 	args := m.Called()
 	return args.Error(0)
 }
 
 type mockOrdersRepository struct{ mock.Mock }
 
-func (m *mockOrdersRepository) Get(orderUID string) (model.Order, error) {
+func (m *mockOrdersRepository) Get(_ context.Context, orderUID string) (model.Order, error) {
 	args := m.Called(orderUID)
 	return args.Get(0).(model.Order), args.Error(1)
 }
-func (m *mockOrdersRepository) Store(order *model.Order) error {
+func (m *mockOrdersRepository) Store(_ context.Context, order *model.Order) (StoreResult, error) {
 	args := m.Called(order)
-	return args.Error(0)
+	return args.Get(0).(StoreResult), args.Error(1)
+}
+func (m *mockOrdersRepository) GetAsOf(_ context.Context, orderUID string, at time.Time) (model.Order, error) {
+	args := m.Called(orderUID, at)
+	return args.Get(0).(model.Order), args.Error(1)
+}
+func (m *mockOrdersRepository) OrderHistory(_ context.Context, orderUID string) ([]model.OrderVersion, error) {
+	args := m.Called(orderUID)
+	return args.Get(0).([]model.OrderVersion), args.Error(1)
+}
+func (m *mockOrdersRepository) SearchOrders(_ context.Context, search model.OrderSearch) (model.OrderSearchResult, error) {
+	args := m.Called(search)
+	return args.Get(0).(model.OrderSearchResult), args.Error(1)
+}
+func (m *mockOrdersRepository) ListOrders(_ context.Context, _ model.OrderListing) ([]model.Order, error) {
+	return nil, nil
+}
+func (m *mockOrdersRepository) ForEachOrderUID(_ context.Context, _ func(string) error) error {
+	return nil
 }
-func (m *mockOrdersRepository) SaveInboxMessage(_ context.Context, _, _, _ string) error {
+func (m *mockOrdersRepository) SaveInboxMessage(_ context.Context, _ model.InboxMessage) error {
 	return nil
 }
 func (m *mockOrdersRepository) FetchUnprocessedInboxMessages(_ context.Context, _ int) ([]model.InboxMessage, error) {
@@ -46,6 +78,54 @@ func (m *mockOrdersRepository) FetchUnprocessedInboxMessages(_ context.Context,
 func (m *mockOrdersRepository) MarkInboxMessageProcessed(_ context.Context, _ string) error {
 	return nil
 }
+func (m *mockOrdersRepository) MarkInboxMessageDeadLettered(_ context.Context, _ string) error {
+	return nil
+}
+func (m *mockOrdersRepository) RecordInboxMessageFailure(_ context.Context, _, _ string) (int, error) {
+	return 0, nil
+}
+func (m *mockOrdersRepository) PurgeInboxMessages(_ context.Context, _ model.InboxPurge) (int, error) {
+	return 0, nil
+}
+func (m *mockOrdersRepository) InboxBacklog(_ context.Context) (model.InboxBacklog, error) {
+	return model.InboxBacklog{}, nil
+}
+
+func validOrder() *model.Order {
+	uid := uuid.New()
+	return &model.Order{
+		OrderUID:        uid,
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		DateCreated:     time.Now().Add(-time.Hour),
+		Delivery: model.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Email:   "test@gmail.com",
+		},
+		Payment: model.Payment{
+			Transaction:  uid,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       model.NewMoney(1817, "USD"),
+			DeliveryCost: model.NewMoney(1500, "USD"),
+			GoodsTotal:   model.NewMoney(317, "USD"),
+		},
+		Items: []model.Item{{
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       model.NewMoney(453, "USD"),
+			Rid:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			TotalPrice:  model.NewMoney(317, "USD"),
+		}},
+	}
+}
 
 // ----- Тесты -----
 func TestGetOrder_FromCacheSuccess(t *testing.T) {
@@ -56,9 +136,9 @@ func TestGetOrder_FromCacheSuccess(t *testing.T) {
 	expectedOrder := model.Order{OrderUID: uid}
 	cacher.On("GetOrderFromCache", uid.String()).Return(expectedOrder, nil)
 
-	service := NewOrdersService(cacher, repo)
+	service := NewOrdersService(cacher, repo, nil, nil)
 
-	order, err := service.GetOrder(uid.String())
+	order, err := service.GetOrder(context.Background(), uid.String())
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedOrder, order)
@@ -71,19 +151,158 @@ func TestGetOrder_FromRepoAfterCacheMiss(t *testing.T) {
 	uid := uuid.New()
 	expectedOrder := model.Order{OrderUID: uid}
 	cacher.On("GetOrderFromCache", uid.String()).Return(model.Order{}, errors.New("not found"))
+	cacher.On("CacheIfAbsent", &expectedOrder).Return(nil)
+	repo.On("Get", uid.String()).Return(expectedOrder, nil)
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	order, err := service.GetOrder(context.Background(), uid.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOrder, order)
+	// Прочитанный из БД заказ возвращается в кэш
+	cacher.AssertCalled(t, "CacheIfAbsent", &expectedOrder)
+}
+
+func TestGetOrder_CacheWriteErrorIgnored(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	uid := uuid.New()
+	expectedOrder := model.Order{OrderUID: uid}
+	cacher.On("GetOrderFromCache", uid.String()).Return(model.Order{}, errors.New("not found"))
+	cacher.On("CacheIfAbsent", mock.Anything).Return(errors.New("redis down"))
 	repo.On("Get", uid.String()).Return(expectedOrder, nil)
 
-	service := NewOrdersService(cacher, repo)
+	service := NewOrdersService(cacher, repo, nil, nil)
 
-	order, err := service.GetOrder(uid.String())
+	order, err := service.GetOrder(context.Background(), uid.String())
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedOrder, order)
 }
 
+func TestGetOrder_QuarantinedNotCached(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	uid := uuid.New()
+	expectedOrder := model.Order{OrderUID: uid, ValidationStatus: model.ValidationQuarantined}
+	cacher.On("GetOrderFromCache", uid.String()).Return(model.Order{}, errors.New("not found"))
+	repo.On("Get", uid.String()).Return(expectedOrder, nil)
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	_, err := service.GetOrder(context.Background(), uid.String())
+
+	assert.NoError(t, err)
+	cacher.AssertNotCalled(t, "CacheIfAbsent", mock.Anything)
+}
+
+func TestGetOrder_ConcurrentMissesReadOnce(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	uid := uuid.New()
+	expectedOrder := model.Order{OrderUID: uid, Items: []model.Item{{Rid: "r1"}}}
+	cacher.On("GetOrderFromCache", uid.String()).Return(model.Order{}, errors.New("not found"))
+	cacher.On("CacheIfAbsent", mock.Anything).Return(nil)
+	// Медленная БД: все запросы успевают встать в ожидание первого
+	repo.On("Get", uid.String()).After(100*time.Millisecond).Return(expectedOrder, nil)
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	const readers = 10
+	var wg sync.WaitGroup
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, err := service.GetOrder(context.Background(), uid.String())
+			assert.NoError(t, err)
+			assert.Equal(t, expectedOrder, order)
+		}()
+	}
+	wg.Wait()
+
+	repo.AssertNumberOfCalls(t, "Get", 1)
+	cacher.AssertNumberOfCalls(t, "CacheIfAbsent", 1)
+}
+
+func TestGetOrder_NegativeCacheHit(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	uid := uuid.New()
+	cacher.On("GetOrderFromCache", uid.String()).Return(model.Order{}, ErrOrderNotFound)
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	_, err := service.GetOrder(context.Background(), uid.String())
+
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	repo.AssertNotCalled(t, "Get", mock.Anything)
+}
+
+func TestGetOrder_NotFoundIsCached(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	uid := uuid.New()
+	cacher.On("GetOrderFromCache", uid.String()).Return(model.Order{}, ErrCacheMiss)
+	cacher.On("CacheNotFound", uid.String()).Return(nil)
+	repo.On("Get", uid.String()).Return(model.Order{}, ErrOrderNotFound)
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	_, err := service.GetOrder(context.Background(), uid.String())
+
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	cacher.AssertCalled(t, "CacheNotFound", uid.String())
+}
+
+// stubFilter знает только перечисленные заказы
+type stubFilter map[string]bool
+
+func (f stubFilter) Add(orderUID string)           { f[orderUID] = true }
+func (f stubFilter) MayExist(orderUID string) bool { return f[orderUID] }
+
+func TestGetOrder_FilterRejectsUnknown(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	service := NewOrdersService(cacher, repo, nil, stubFilter{})
+
+	_, err := service.GetOrder(context.Background(), uuid.NewString())
+
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	cacher.AssertNotCalled(t, "GetOrderFromCache", mock.Anything)
+	repo.AssertNotCalled(t, "Get", mock.Anything)
+}
+
+func TestFilteredRepository_AddsStoredOrders(t *testing.T) {
+	repo := new(mockOrdersRepository)
+	filter := stubFilter{}
+
+	order := validOrder()
+	failed := validOrder()
+	repo.On("Store", order).Return(StoreCreated, nil)
+	repo.On("Store", failed).Return(StoreResult(""), errors.New("db error"))
+
+	filtered := NewFilteredRepository(repo, filter)
+
+	_, err := filtered.Store(context.Background(), order)
+	assert.NoError(t, err)
+	_, err = filtered.Store(context.Background(), failed)
+	assert.Error(t, err)
+
+	assert.True(t, filter.MayExist(order.OrderUID.String()))
+	assert.False(t, filter.MayExist(failed.OrderUID.String()))
+}
+
 func TestGetOrder_EmptyUID(t *testing.T) {
-	service := NewOrdersService(nil, nil)
-	order, err := service.GetOrder("")
+	service := NewOrdersService(nil, nil, nil, nil)
+	order, err := service.GetOrder(context.Background(), "")
 
 	assert.Error(t, err)
 	assert.Empty(t, order)
@@ -97,9 +316,9 @@ func TestGetOrder_RepoError(t *testing.T) {
 	cacher.On("GetOrderFromCache", uid.String()).Return(model.Order{}, errors.New("not found"))
 	repo.On("Get", uid.String()).Return(model.Order{}, errors.New("db error"))
 
-	service := NewOrdersService(cacher, repo)
+	service := NewOrdersService(cacher, repo, nil, nil)
 
-	order, err := service.GetOrder(uid.String())
+	order, err := service.GetOrder(context.Background(), uid.String())
 
 	assert.Error(t, err)
 	assert.Empty(t, order.OrderUID)
@@ -109,21 +328,22 @@ func TestSaveOrder_Success(t *testing.T) {
 	cacher := new(mockCacher)
 	repo := new(mockOrdersRepository)
 
-	uid := uuid.New()
-	order := &model.Order{OrderUID: uid}
+	order := validOrder()
 	cacher.On("Cache", order).Return(nil)
-	repo.On("Store", order).Return(nil)
+	repo.On("Store", order).Return(StoreCreated, nil)
 
-	service := NewOrdersService(cacher, repo)
+	service := NewOrdersService(cacher, repo, nil, nil)
 
-	err := service.SaveOrder(order)
+	result, err := service.SaveOrder(context.Background(), order)
 
 	assert.NoError(t, err)
+	assert.Equal(t, StoreCreated, result)
+	cacher.AssertCalled(t, "Cache", order)
 }
 
 func TestSaveOrder_NilOrder(t *testing.T) {
-	service := NewOrdersService(nil, nil)
-	err := service.SaveOrder(nil)
+	service := NewOrdersService(nil, nil, nil, nil)
+	_, err := service.SaveOrder(context.Background(), nil)
 
 	assert.Error(t, err)
 }
@@ -132,29 +352,118 @@ func TestSaveOrder_CacheError(t *testing.T) {
 	cacher := new(mockCacher)
 	repo := new(mockOrdersRepository)
 
-	uid := uuid.New()
-	order := &model.Order{OrderUID: uid}
+	order := validOrder()
 	cacher.On("Cache", order).Return(errors.New("cache error"))
+	repo.On("Store", order).Return(StoreCreated, nil)
 
-	service := NewOrdersService(cacher, repo)
+	service := NewOrdersService(cacher, repo, nil, nil)
 
-	err := service.SaveOrder(order)
+	result, err := service.SaveOrder(context.Background(), order)
 
-	assert.Error(t, err)
+	assert.NoError(t, err)
+	assert.Equal(t, StoreCreated, result)
+	cacher.AssertExpectations(t)
 }
 
 func TestSaveOrder_RepoError(t *testing.T) {
 	cacher := new(mockCacher)
 	repo := new(mockOrdersRepository)
 
-	uid := uuid.New()
-	order := &model.Order{OrderUID: uid}
-	cacher.On("Cache", order).Return(nil)
-	repo.On("Store", order).Return(errors.New("db error"))
+	order := validOrder()
+	repo.On("Store", order).Return(StoreResult(""), errors.New("db error"))
 
-	service := NewOrdersService(cacher, repo)
+	service := NewOrdersService(cacher, repo, nil, nil)
 
-	err := service.SaveOrder(order)
+	_, err := service.SaveOrder(context.Background(), order)
 
 	assert.Error(t, err)
+	cacher.AssertNotCalled(t, "Cache", order)
+}
+
+func TestSaveOrder_UnchangedSkipsCache(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	order := validOrder()
+	repo.On("Store", order).Return(StoreUnchanged, nil)
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	result, err := service.SaveOrder(context.Background(), order)
+
+	assert.NoError(t, err)
+	assert.Equal(t, StoreUnchanged, result)
+	cacher.AssertNotCalled(t, "Cache", order)
+}
+
+func TestSaveOrder_ValidationError(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	order := validOrder()
+	order.Payment.Amount.Minor = -1
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	_, err := service.SaveOrder(context.Background(), order)
+
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	cacher.AssertNotCalled(t, "Cache", order)
+	repo.AssertNotCalled(t, "Store", order)
+}
+
+func TestGetOrderAsOf_BypassesCache(t *testing.T) {
+	cacher := new(mockCacher)
+	repo := new(mockOrdersRepository)
+
+	uid := uuid.New()
+	at := time.Now().Add(-time.Hour)
+	expectedOrder := model.Order{OrderUID: uid}
+	repo.On("GetAsOf", uid.String(), at).Return(expectedOrder, nil)
+
+	service := NewOrdersService(cacher, repo, nil, nil)
+
+	order, err := service.GetOrderAsOf(context.Background(), uid.String(), at)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOrder, order)
+	cacher.AssertNotCalled(t, "GetOrderFromCache", uid.String())
+}
+
+func TestGetOrderHistory_NotFound(t *testing.T) {
+	repo := new(mockOrdersRepository)
+
+	uid := uuid.New()
+	repo.On("OrderHistory", uid.String()).Return([]model.OrderVersion(nil), ErrOrderNotFound)
+
+	service := NewOrdersService(nil, repo, nil, nil)
+
+	_, err := service.GetOrderHistory(context.Background(), uid.String())
+
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestSearchOrders_NormalizesPage(t *testing.T) {
+	repo := new(mockOrdersRepository)
+	want := model.OrderSearch{Query: "Vivienne Sabo", Limit: MaxSearchLimit, Offset: 0}
+	repo.On("SearchOrders", want).Return(model.OrderSearchResult{Total: 1}, nil)
+
+	service := NewOrdersService(nil, repo, nil, nil)
+
+	result, err := service.SearchOrders(context.Background(), model.OrderSearch{Query: "  Vivienne Sabo ", Limit: 1000, Offset: -5})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchOrders_EmptyQuery(t *testing.T) {
+	repo := new(mockOrdersRepository)
+	service := NewOrdersService(nil, repo, nil, nil)
+
+	_, err := service.SearchOrders(context.Background(), model.OrderSearch{Query: "   "})
+
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+	repo.AssertNotCalled(t, "SearchOrders", mock.Anything)
 }
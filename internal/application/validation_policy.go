@@ -0,0 +1,191 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+// Severity — что делать с заказом, нарушившим правило
+type Severity string
+
+const (
+	// SeverityReject — заказ не сохраняется
+	SeverityReject Severity = "reject"
+	// SeverityQuarantine — заказ сохраняется со статусом quarantined и не кэшируется
+	SeverityQuarantine Severity = "quarantine"
+	// SeverityWarn — заказ сохраняется со статусом warned
+	SeverityWarn Severity = "warn"
+)
+
+// Режимы — готовые наборы severity по правилам
+const (
+	ValidationModeStrict   = "strict"
+	ValidationModeLenient  = "lenient"
+	ValidationModeWarnOnly = "warn_only"
+)
+
+var severityRank = map[Severity]int{
+	SeverityWarn:       1,
+	SeverityQuarantine: 2,
+	SeverityReject:     3,
+}
+
+var lenientSeverities = map[string]Severity{
+	model.RuleRequired:      SeverityReject,
+	model.RuleNonNegative:   SeverityReject,
+	model.RuleItemTotal:     SeverityQuarantine,
+	model.RuleGoodsTotal:    SeverityQuarantine,
+	model.RulePaymentAmount: SeverityQuarantine,
+	model.RuleCurrency:      SeverityWarn,
+	model.RuleLocale:        SeverityWarn,
+	model.RuleEmail:         SeverityWarn,
+	model.RulePhone:         SeverityWarn,
+	model.RuleDateCreated:   SeverityWarn,
+}
+
+type ruleSeverities struct {
+	mode  string
+	rules map[string]Severity
+}
+
+// ValidationPolicy решает, как поступить с нарушениями валидации.
+// Severity правила ищется по приоритету: правило для entry, для топика,
+// глобальное правило, затем режим entry, топика и глобальный режим.
+type ValidationPolicy struct {
+	base    ruleSeverities
+	topics  map[string]ruleSeverities
+	entries map[string]ruleSeverities
+}
+
+func NewValidationPolicy(cfg config.ValidationConfig) (*ValidationPolicy, error) {
+	if cfg.Mode == "" {
+		cfg.Mode = ValidationModeStrict
+	}
+
+	base, err := parseRuleSeverities(cfg.Mode, cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ValidationPolicy{
+		base:    base,
+		topics:  make(map[string]ruleSeverities, len(cfg.Topics)),
+		entries: make(map[string]ruleSeverities, len(cfg.Entries)),
+	}
+
+	for topic, o := range cfg.Topics {
+		if p.topics[topic], err = parseRuleSeverities(o.Mode, o.Rules); err != nil {
+			return nil, fmt.Errorf("topic %s: %w", topic, err)
+		}
+	}
+	for entry, o := range cfg.Entries {
+		if p.entries[entry], err = parseRuleSeverities(o.Mode, o.Rules); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", entry, err)
+		}
+	}
+
+	return p, nil
+}
+
+func parseRuleSeverities(mode string, rules map[string]string) (ruleSeverities, error) {
+	switch mode {
+	case "", ValidationModeStrict, ValidationModeLenient, ValidationModeWarnOnly:
+	default:
+		return ruleSeverities{}, fmt.Errorf("unknown validation mode %q", mode)
+	}
+
+	res := ruleSeverities{mode: mode, rules: make(map[string]Severity, len(rules))}
+	for rule, sev := range rules {
+		if !isRule(rule) {
+			return ruleSeverities{}, fmt.Errorf("unknown validation rule %q", rule)
+		}
+		if _, ok := severityRank[Severity(sev)]; !ok {
+			return ruleSeverities{}, fmt.Errorf("unknown severity %q for rule %s", sev, rule)
+		}
+		res.rules[rule] = Severity(sev)
+	}
+
+	return res, nil
+}
+
+func isRule(rule string) bool {
+	for _, r := range model.Rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply валидирует заказ и проставляет ему ValidationStatus и ValidationWarnings.
+// Если хотя бы одно нарушение имеет severity reject, возвращает
+// *model.ValidationError. Прочие ошибки Validate возвращаются как есть.
+// Nil-политика работает как strict.
+func (p *ValidationPolicy) Apply(order *model.Order, topic string) error {
+	order.ValidationStatus = model.ValidationValid
+	order.ValidationWarnings = nil
+
+	err := order.Validate()
+	if err == nil {
+		return nil
+	}
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+
+	worst := SeverityWarn
+	for _, fe := range verr.Errors {
+		sev := p.severity(fe.Rule, topic, order.Entry)
+		if severityRank[sev] > severityRank[worst] {
+			worst = sev
+		}
+	}
+
+	switch worst {
+	case SeverityReject:
+		return verr
+	case SeverityQuarantine:
+		order.ValidationStatus = model.ValidationQuarantined
+	default:
+		order.ValidationStatus = model.ValidationWarned
+	}
+	order.ValidationWarnings = verr.Errors
+
+	return nil
+}
+
+func (p *ValidationPolicy) severity(rule, topic, entry string) Severity {
+	if p == nil || rule == model.RuleUniqueRid {
+		return SeverityReject
+	}
+
+	layers := []ruleSeverities{p.entries[entry], p.topics[topic], p.base}
+
+	for _, l := range layers {
+		if sev, ok := l.rules[rule]; ok {
+			return sev
+		}
+	}
+	for _, l := range layers {
+		if l.mode != "" {
+			return modeSeverity(l.mode, rule)
+		}
+	}
+
+	return SeverityReject
+}
+
+func modeSeverity(mode, rule string) Severity {
+	switch mode {
+	case ValidationModeWarnOnly:
+		return SeverityWarn
+	case ValidationModeLenient:
+		return lenientSeverities[rule]
+	default:
+		return SeverityReject
+	}
+}
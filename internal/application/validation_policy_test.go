@@ -0,0 +1,85 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationPolicy_NilIsStrict(t *testing.T) {
+	var policy *ValidationPolicy
+
+	order := validOrder()
+	order.Delivery.Email = "broken"
+
+	assert.Error(t, policy.Apply(order, "order_created"))
+}
+
+func TestValidationPolicy_Severities(t *testing.T) {
+	var cfg config.ValidationConfig
+	cfg.Mode = ValidationModeStrict
+	cfg.Rules = map[string]string{model.RuleEmail: "warn"}
+	cfg.Topics = map[string]config.ValidationOverride{
+		"legacy": {Mode: ValidationModeLenient},
+	}
+	cfg.Entries = map[string]config.ValidationOverride{
+		"TEST": {Rules: map[string]string{model.RulePhone: "quarantine"}},
+	}
+
+	policy, err := NewValidationPolicy(cfg)
+	assert.NoError(t, err)
+
+	// глобальное правило: email — warn
+	order := validOrder()
+	order.Delivery.Email = "broken"
+	assert.NoError(t, policy.Apply(order, "order_created"))
+	assert.Equal(t, model.ValidationWarned, order.ValidationStatus)
+	assert.Len(t, order.ValidationWarnings, 1)
+
+	// strict по умолчанию: сумма не сходится — reject
+	order = validOrder()
+	order.Payment.Amount.Minor = 1
+	assert.Error(t, policy.Apply(order, "order_created"))
+
+	// lenient для топика: сумма не сходится — quarantine
+	assert.NoError(t, policy.Apply(order, "legacy"))
+	assert.Equal(t, model.ValidationQuarantined, order.ValidationStatus)
+
+	// правило для entry важнее режима
+	order = validOrder()
+	order.Entry = "TEST"
+	order.Delivery.Phone = "none"
+	assert.NoError(t, policy.Apply(order, "order_created"))
+	assert.Equal(t, model.ValidationQuarantined, order.ValidationStatus)
+
+	// валидный заказ
+	order = validOrder()
+	assert.NoError(t, policy.Apply(order, "order_created"))
+	assert.Equal(t, model.ValidationValid, order.ValidationStatus)
+	assert.Empty(t, order.ValidationWarnings)
+}
+
+func TestValidationPolicy_DuplicateRidAlwaysRejected(t *testing.T) {
+	var cfg config.ValidationConfig
+	cfg.Mode = ValidationModeWarnOnly
+
+	policy, err := NewValidationPolicy(cfg)
+	assert.NoError(t, err)
+
+	order := validOrder()
+	order.Items = append(order.Items, order.Items[0])
+	order.Payment.GoodsTotal.Minor *= 2
+	order.Payment.Amount.Minor += order.Items[0].TotalPrice.Minor
+
+	assert.Error(t, policy.Apply(order, "order_created"))
+}
+
+func TestNewValidationPolicy_UnknownRule(t *testing.T) {
+	var cfg config.ValidationConfig
+	cfg.Rules = map[string]string{"colour": "warn"}
+
+	_, err := NewValidationPolicy(cfg)
+	assert.Error(t, err)
+}
@@ -8,32 +8,47 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// Варианты хранилища заказов
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// Варианты кэша заказов при storage: postgres
+const (
+	CacheRedis  = "redis"
+	CacheMemory = "memory"
+	CacheTiered = "tiered"
+)
+
 type Config struct {
 	Server struct {
 		Port int `yaml:"port"`
 	} `yaml:"server"`
 
-	DataBase struct {
-		Host     string `yaml:"host"`
-		Port     int    `yaml:"port"`
-		User     string `yaml:"user"`
-		Password string `yaml:"password"`
-		Name     string `yaml:"name"`
-	} `yaml:"database"`
-
-	RedisConfig struct {
-		Host     string        `yaml:"host"`
-		Port     int           `yaml:"port"`
-		Password string        `yaml:"password"`
-		DB       int           `yaml:"db"`
-		TTL      time.Duration `yaml:"ttl"`
-	} `yaml:"redis"`
-
-	KafkaConfig struct {
-		Broker  string `yaml:"broker"`
-		Topic   string `yaml:"topic"`
-		GroupID string `yaml:"group_id"`
-	} `yaml:"kafka"`
+	// Storage — postgres (Postgres + Redis) или memory (всё в памяти процесса)
+	Storage string `yaml:"storage" env-default:"postgres"`
+
+	DataBase DBConfig `yaml:"database"`
+
+	RedisConfig RedisConfig `yaml:"redis"`
+
+	// Cache — redis, memory (LRU в процессе) или tiered (LRU перед Redis)
+	Cache struct {
+		Mode string `yaml:"mode" env-default:"redis"`
+		// NegativeTTL — сколько помнить, что заказа нет, на всех уровнях
+		// кэша; 0 — не запоминать
+		NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"5s"`
+		Memory      LRUConfig     `yaml:"memory"`
+		// Bloom — фильтр существующих order_uid перед кэшем и БД
+		Bloom BloomConfig `yaml:"bloom"`
+	} `yaml:"cache"`
+
+	KafkaConfig KafkaConfig `yaml:"kafka"`
+
+	InboxConfig InboxConfig `yaml:"inbox"`
+
+	Validation ValidationConfig `yaml:"validation"`
 
 	LoggerConfig struct {
 		Level  string `yaml:"level"`
@@ -41,6 +56,197 @@ type Config struct {
 	} `yaml:"logger"`
 }
 
+// InboxConfig — обработка сообщений inbox
+type InboxConfig struct {
+	PollInterval time.Duration   `yaml:"poll_interval" env-default:"2s"`
+	BatchSize    int             `yaml:"batch_size" env-default:"10"`
+	Mode         string          `yaml:"mode" env-default:"sequential"`
+	Workers      int             `yaml:"workers" env-default:"4"`
+	MaxAttempts  int             `yaml:"max_attempts" env-default:"5"`
+	Retention    RetentionConfig `yaml:"retention"`
+}
+
+// RetentionConfig — сроки хранения обработанных сообщений inbox
+type RetentionConfig struct {
+	Enabled             bool          `yaml:"enabled" env-default:"false"`
+	Interval            time.Duration `yaml:"interval" env-default:"10m"`
+	ProcessedTTL        time.Duration `yaml:"processed_ttl" env-default:"168h"`
+	DeadLetterTTL       time.Duration `yaml:"dead_letter_ttl" env-default:"720h"`
+	BatchSize           int           `yaml:"batch_size" env-default:"500"`
+	Archive             bool          `yaml:"archive" env-default:"false"`
+	PauseBetweenBatches time.Duration `yaml:"pause_between_batches" env-default:"100ms"`
+}
+
+// KafkaConfig — чтение заказов из Kafka
+type KafkaConfig struct {
+	Broker       string             `yaml:"broker"`
+	Topic        string             `yaml:"topic"`
+	GroupID      string             `yaml:"group_id"`
+	Backpressure BackpressureConfig `yaml:"backpressure"`
+}
+
+// BackpressureConfig — пороги приостановки чтения из Kafka
+type BackpressureConfig struct {
+	Enabled         bool          `yaml:"enabled" env-default:"false"`
+	CheckInterval   time.Duration `yaml:"check_interval" env-default:"5s"`
+	MaxPending      int           `yaml:"max_pending" env-default:"10000"`
+	ResumePending   int           `yaml:"resume_pending"`
+	MaxOldestAge    time.Duration `yaml:"max_oldest_age" env-default:"5m"`
+	ResumeOldestAge time.Duration `yaml:"resume_oldest_age"`
+}
+
+// ValidationConfig — политика валидации заказов: режим и severity правил
+type ValidationConfig struct {
+	Mode    string                        `yaml:"mode" env-default:"strict"`
+	Rules   map[string]string             `yaml:"rules"`
+	Topics  map[string]ValidationOverride `yaml:"topics"`
+	Entries map[string]ValidationOverride `yaml:"entries"`
+}
+
+// ValidationOverride — режим и severity правил для топика или entry
+type ValidationOverride struct {
+	Mode  string            `yaml:"mode"`
+	Rules map[string]string `yaml:"rules"`
+}
+
+// DBConfig — подключение к Postgres и обслуживание схемы
+type DBConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+
+	// DSN целиком заменяет строку подключения из полей выше
+	DSN string `yaml:"dsn"`
+
+	// TLS: sslmode и пути к сертификатам
+	SSLMode     string `yaml:"sslmode" env-default:"disable"`
+	SSLRootCert string `yaml:"sslrootcert"`
+	SSLCert     string `yaml:"sslcert"`
+	SSLKey      string `yaml:"sslkey"`
+
+	// Параметры сессии
+	ApplicationName  string        `yaml:"application_name" env-default:"wb-orders-service"`
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+
+	Pool    PoolConfig    `yaml:"pool"`
+	Connect ConnectConfig `yaml:"connect"`
+
+	// Таймауты операций репозитория
+	ReadTimeout  time.Duration `yaml:"read_timeout" env-default:"3s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"5s"`
+
+	// AutoMigrate — применять встроенные миграции при старте вместо
+	// отказа запускаться на устаревшей схеме
+	AutoMigrate bool `yaml:"auto_migrate" env-default:"false"`
+
+	// StoreMode — insert или upsert
+	StoreMode string `yaml:"store_mode" env-default:"upsert"`
+
+	// Partitioning — обслуживание месячных партиций orders/items
+	Partitioning PartitionConfig `yaml:"partitioning"`
+
+	// Replicas — реплики для чтения заказов
+	Replicas ReplicaConfig `yaml:"replicas"`
+}
+
+// PoolConfig — настройки пула соединений. Те же настройки получают пулы
+// реплик.
+type PoolConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" env-default:"25"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env-default:"5"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"5m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// ConnectConfig — повторы подключения при старте сервиса
+type ConnectConfig struct {
+	Attempts       int           `yaml:"attempts" env-default:"5"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"500ms"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"10s"`
+}
+
+// PartitionConfig — обслуживание месячных партиций orders/items
+type PartitionConfig struct {
+	Enabled       bool          `yaml:"enabled" env-default:"false"`
+	Interval      time.Duration `yaml:"interval" env-default:"1h"`
+	PremakeMonths int           `yaml:"premake_months" env-default:"3"`
+	ArchiveAfter  int           `yaml:"archive_after_months" env-default:"12"`
+	LockTimeout   time.Duration `yaml:"lock_timeout" env-default:"5s"`
+}
+
+// ReplicaConfig — реплики для чтения и их проверка
+type ReplicaConfig struct {
+	DSNs                []string      `yaml:"dsns"`
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" env-default:"5s"`
+	HealthCheckTimeout  time.Duration `yaml:"health_check_timeout" env-default:"1s"`
+	MaxLag              time.Duration `yaml:"max_lag" env-default:"10s"`
+	ReadYourWrites      time.Duration `yaml:"read_your_writes" env-default:"15s"`
+}
+
+// RedisConfig — подключение к Redis и формат записей кэша
+type RedisConfig struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Password string        `yaml:"password"`
+	DB       int           `yaml:"db"`
+	TTL      time.Duration `yaml:"ttl"`
+
+	// Таймауты операций кэша
+	ReadTimeout  time.Duration `yaml:"read_timeout" env-default:"500ms"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"1s"`
+
+	// Namespace — префикс ключей заказов. Смена префикса сбрасывает кэш.
+	Namespace string      `yaml:"namespace" env-default:"orders:v2:"`
+	Codec     CodecConfig `yaml:"codec"`
+
+	WarmUp WarmUpConfig `yaml:"warm_up"`
+}
+
+// WarmUpConfig — прогрев кэша после старта
+type WarmUpConfig struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// Count — сколько последних заказов прогреть
+	Count int `yaml:"count" env-default:"1000"`
+	// MaxAge — только заказы не старше; 0 — без ограничения
+	MaxAge    time.Duration `yaml:"max_age" env-default:"0"`
+	BatchSize int           `yaml:"batch_size" env-default:"200"`
+}
+
+// CodecConfig — формат записей кэша
+type CodecConfig struct {
+	// Encoding — json, msgpack или gob
+	Encoding string `yaml:"encoding" env-default:"msgpack"`
+	// Compression — none или gzip
+	Compression string `yaml:"compression" env-default:"none"`
+	// CompressMinSize — значения короче не сжимаются
+	CompressMinSize int `yaml:"compress_min_size" env-default:"1024"`
+}
+
+// LRUConfig — кэш заказов в памяти процесса
+type LRUConfig struct {
+	// MaxEntries — сколько заказов держать; при переполнении вытесняется
+	// давно не читанный
+	MaxEntries int `yaml:"max_entries" env-default:"10000"`
+	// TTL — сколько заказ живёт в памяти. Записи других экземпляров сервиса
+	// сюда не доходят, поэтому TTL ограничивает устаревание.
+	TTL time.Duration `yaml:"ttl" env-default:"30s"`
+}
+
+// BloomConfig — фильтр существующих order_uid
+type BloomConfig struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// ExpectedOrders — на сколько заказов рассчитан фильтр. При перестройке
+	// размер растёт вместе с числом заказов.
+	ExpectedOrders    int     `yaml:"expected_orders" env-default:"1000000"`
+	FalsePositiveRate float64 `yaml:"false_positive_rate" env-default:"0.01"`
+	// RebuildInterval — как часто перечитывать все order_uid. Заказы других
+	// экземпляров сервиса приходят через Add по уведомлениям Postgres, а
+	// перестройка убирает удалённые заказы и держит размер по числу заказов.
+	RebuildInterval time.Duration `yaml:"rebuild_interval" env-default:"5m"`
+}
+
 func MustLoad() *Config {
 	path := FetchConfigPath()
 	if path == "" {
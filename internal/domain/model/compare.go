@@ -0,0 +1,69 @@
+package model
+
+import "github.com/google/uuid"
+
+// SameContent сообщает, совпадают ли данные двух заказов. Служебные поля
+// (id товаров, ссылки на заказ, список предупреждений валидации, версия)
+// не сравниваются, товары сопоставляются по rid.
+func (o *Order) SameContent(other *Order) bool {
+	if o.OrderUID != other.OrderUID ||
+		o.TrackNumber != other.TrackNumber ||
+		o.Entry != other.Entry ||
+		o.Locale != other.Locale ||
+		o.InternalSignature != other.InternalSignature ||
+		o.CustomerID != other.CustomerID ||
+		o.DeliveryService != other.DeliveryService ||
+		o.ShardKey != other.ShardKey ||
+		o.SmID != other.SmID ||
+		!o.DateCreated.Equal(other.DateCreated) ||
+		o.OofShard != other.OofShard ||
+		o.ValidationStatus != other.ValidationStatus {
+		return false
+	}
+
+	if !o.Delivery.sameContent(&other.Delivery) || !o.Payment.sameContent(&other.Payment) {
+		return false
+	}
+
+	if len(o.Items) != len(other.Items) {
+		return false
+	}
+	byRid := make(map[string]*Item, len(other.Items))
+	for i := range other.Items {
+		byRid[other.Items[i].Rid] = &other.Items[i]
+	}
+	for i := range o.Items {
+		it, ok := byRid[o.Items[i].Rid]
+		if !ok || !o.Items[i].sameContent(it) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d *Delivery) sameContent(other *Delivery) bool {
+	a, b := *d, *other
+	a.OrderUID, b.OrderUID = uuid.Nil, uuid.Nil
+	return a == b
+}
+
+func (p *Payment) sameContent(other *Payment) bool {
+	return p.Transaction == other.Transaction &&
+		p.RequestID == other.RequestID &&
+		p.Currency == other.Currency &&
+		p.Provider == other.Provider &&
+		p.Amount == other.Amount &&
+		p.PaymentDT.Equal(other.PaymentDT) &&
+		p.Bank == other.Bank &&
+		p.DeliveryCost == other.DeliveryCost &&
+		p.GoodsTotal == other.GoodsTotal &&
+		p.CustomFee == other.CustomFee
+}
+
+func (it *Item) sameContent(other *Item) bool {
+	a, b := *it, *other
+	a.ID, b.ID = 0, 0
+	a.OrderUID, b.OrderUID = uuid.Nil, uuid.Nil
+	return a == b
+}
@@ -1,7 +1,27 @@
 package model
 
+import "time"
+
 type InboxMessage struct {
-	ID      string
+	// ID уникален для каждого сообщения: topic/partition/offset в Kafka
+	ID string
+	// Key — ключ Kafka-сообщения (order_uid). Сообщения с одним ключом
+	// обрабатываются по порядку.
+	Key     string
 	Topic   string
 	Payload string
 }
+
+// InboxBacklog — размер очереди необработанных сообщений inbox
+type InboxBacklog struct {
+	Pending   int
+	OldestAge time.Duration
+}
+
+// InboxPurge — параметры очистки обработанных сообщений inbox
+type InboxPurge struct {
+	DeadLettered bool
+	OlderThan    time.Duration
+	Limit        int
+	Archive      bool
+}
@@ -7,12 +7,12 @@ type Item struct {
 	OrderUID    uuid.UUID `json:"-" db:"order_uid"`
 	ChrtID      int       `json:"chrt_id" db:"chrt_id"`
 	TrackNumber string    `json:"track_number" db:"track_number"`
-	Price       int       `json:"price" db:"price"`
+	Price       Money     `json:"price" db:"price"`
 	Rid         string    `json:"rid" db:"rid"`
 	Name        string    `json:"name" db:"name"`
 	Sale        int       `json:"sale" db:"sale"`
 	Size        string    `json:"size" db:"size"`
-	TotalPrice  int       `json:"total_price" db:"total_price"`
+	TotalPrice  Money     `json:"total_price" db:"total_price"`
 	NmID        int       `json:"nm_id" db:"nm_id"`
 	Brand       string    `json:"brand" db:"brand"`
 	Status      int       `json:"status" db:"status"`
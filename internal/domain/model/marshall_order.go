@@ -5,7 +5,32 @@ import (
 	"time"
 )
 
+// marshalItem — товар в формате заказа. Рядом с суммами в минорных единицах
+// отдаются отформатированные значения, например "18.17 USD".
+type marshalItem struct {
+	ChrtID      int    `json:"chrt_id"`
+	TrackNumber string `json:"track_number"`
+	Price       Money  `json:"price"`
+	Rid         string `json:"rid"`
+	Name        string `json:"name"`
+	Sale        int    `json:"sale"`
+	Size        string `json:"size"`
+	TotalPrice  Money  `json:"total_price"`
+	NmID        int    `json:"nm_id"`
+	Brand       string `json:"brand"`
+	Status      int    `json:"status"`
+
+	PriceFormatted      string `json:"price_formatted"`
+	TotalPriceFormatted string `json:"total_price_formatted"`
+}
+
 func MarshalOrder(order *Order) ([]byte, error) {
+	// Суммы форматируются в валюте платежа, исходный заказ не меняем
+	bound := *order
+	bound.Items = append([]Item(nil), order.Items...)
+	bound.BindCurrency()
+	order = &bound
+
 	// Вспомогательная структура для кастомного маршалинга
 	aux := struct {
 		OrderUID    string `json:"order_uid"`
@@ -25,34 +50,27 @@ func MarshalOrder(order *Order) ([]byte, error) {
 			RequestID    string `json:"request_id"`
 			Currency     string `json:"currency"`
 			Provider     string `json:"provider"`
-			Amount       int    `json:"amount"`
+			Amount       Money  `json:"amount"`
 			PaymentDT    int64  `json:"payment_dt"`
 			Bank         string `json:"bank"`
-			DeliveryCost int    `json:"delivery_cost"`
-			GoodsTotal   int    `json:"goods_total"`
-			CustomFee    int    `json:"custom_fee"`
+			DeliveryCost Money  `json:"delivery_cost"`
+			GoodsTotal   Money  `json:"goods_total"`
+			CustomFee    Money  `json:"custom_fee"`
+
+			AmountFormatted       string `json:"amount_formatted"`
+			DeliveryCostFormatted string `json:"delivery_cost_formatted"`
+			GoodsTotalFormatted   string `json:"goods_total_formatted"`
+			CustomFeeFormatted    string `json:"custom_fee_formatted"`
 		} `json:"payment"`
-		Items []struct {
-			ChrtID      int    `json:"chrt_id"`
-			TrackNumber string `json:"track_number"`
-			Price       int    `json:"price"`
-			Rid         string `json:"rid"`
-			Name        string `json:"name"`
-			Sale        int    `json:"sale"`
-			Size        string `json:"size"`
-			TotalPrice  int    `json:"total_price"`
-			NmID        int    `json:"nm_id"`
-			Brand       string `json:"brand"`
-			Status      int    `json:"status"`
-		} `json:"items"`
-		Locale            string `json:"locale"`
-		InternalSignature string `json:"internal_signature"`
-		CustomerID        string `json:"customer_id"`
-		DeliveryService   string `json:"delivery_service"`
-		ShardKey          string `json:"shardkey"`
-		SmID              int    `json:"sm_id"`
-		DateCreated       string `json:"date_created"`
-		OofShard          string `json:"oof_shard"`
+		Items             []marshalItem `json:"items"`
+		Locale            string        `json:"locale"`
+		InternalSignature string        `json:"internal_signature"`
+		CustomerID        string        `json:"customer_id"`
+		DeliveryService   string        `json:"delivery_service"`
+		ShardKey          string        `json:"shardkey"`
+		SmID              int           `json:"sm_id"`
+		DateCreated       string        `json:"date_created"`
+		OofShard          string        `json:"oof_shard"`
 	}{
 		OrderUID:          order.OrderUID.String(),
 		TrackNumber:       order.TrackNumber,
@@ -87,22 +105,14 @@ func MarshalOrder(order *Order) ([]byte, error) {
 	aux.Payment.DeliveryCost = order.Payment.DeliveryCost
 	aux.Payment.GoodsTotal = order.Payment.GoodsTotal
 	aux.Payment.CustomFee = order.Payment.CustomFee
+	aux.Payment.AmountFormatted = order.Payment.Amount.Format()
+	aux.Payment.DeliveryCostFormatted = order.Payment.DeliveryCost.Format()
+	aux.Payment.GoodsTotalFormatted = order.Payment.GoodsTotal.Format()
+	aux.Payment.CustomFeeFormatted = order.Payment.CustomFee.Format()
 
 	// Заполняем Items
 	for _, item := range order.Items {
-		aux.Items = append(aux.Items, struct {
-			ChrtID      int    `json:"chrt_id"`
-			TrackNumber string `json:"track_number"`
-			Price       int    `json:"price"`
-			Rid         string `json:"rid"`
-			Name        string `json:"name"`
-			Sale        int    `json:"sale"`
-			Size        string `json:"size"`
-			TotalPrice  int    `json:"total_price"`
-			NmID        int    `json:"nm_id"`
-			Brand       string `json:"brand"`
-			Status      int    `json:"status"`
-		}{
+		aux.Items = append(aux.Items, marshalItem{
 			ChrtID:      item.ChrtID,
 			TrackNumber: item.TrackNumber,
 			Price:       item.Price,
@@ -114,6 +124,9 @@ func MarshalOrder(order *Order) ([]byte, error) {
 			NmID:        item.NmID,
 			Brand:       item.Brand,
 			Status:      item.Status,
+
+			PriceFormatted:      item.Price.Format(),
+			TotalPriceFormatted: item.TotalPrice.Format(),
 		})
 	}
 
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch — арифметика над суммами в разных валютах
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money — сумма в минорных единицах валюты (центах, копейках; для JPY —
+// в иенах, для KWD — в филсах). В JSON и БД хранится только число минорных
+// единиц, валюта берётся из payment.currency заказа (см. Order.BindCurrency).
+// Пустая Currency — сумма ещё не привязана к валюте и совместима с любой.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+// Add складывает суммы одной валюты
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := commonCurrency(m.Currency, other.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: m.Minor + other.Minor, Currency: currency}, nil
+}
+
+// SumMoney складывает суммы одной валюты
+func SumMoney(values ...Money) (Money, error) {
+	var total Money
+	for _, v := range values {
+		var err error
+		if total, err = total.Add(v); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Discount возвращает сумму со скидкой percent процентов, округлённую вниз
+// до минорной единицы
+func (m Money) Discount(percent int) Money {
+	return Money{Minor: m.Minor * int64(100-percent) / 100, Currency: m.Currency}
+}
+
+// Format — сумма в основных единицах с кодом валюты: "18.17 USD",
+// "1817 JPY". Без валюты экспонента считается равной 2.
+func (m Money) Format() string {
+	exp := CurrencyExponent(m.Currency)
+
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+
+	digits := strconv.FormatInt(minor, 10)
+	if exp > 0 {
+		if len(digits) <= exp {
+			digits = strings.Repeat("0", exp-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+	}
+
+	if m.Currency == "" {
+		return sign + digits
+	}
+	return sign + digits + " " + m.Currency
+}
+
+func (m Money) String() string {
+	return m.Format()
+}
+
+// MarshalJSON пишет число минорных единиц, как в исходном формате заказа
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(m.Minor, 10)), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &m.Minor)
+}
+
+// Value хранит в БД число минорных единиц
+func (m Money) Value() (driver.Value, error) {
+	return m.Minor, nil
+}
+
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		m.Minor = v
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+		m.Minor = n
+	case nil:
+		m.Minor = 0
+	default:
+		return fmt.Errorf("unsupported money type %T", src)
+	}
+	return nil
+}
+
+func commonCurrency(a, b string) (string, error) {
+	switch {
+	case a == "":
+		return b, nil
+	case b == "" || a == b:
+		return a, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a, b)
+	}
+}
+
+// BindCurrency привязывает все суммы заказа к валюте платежа. Вызывается
+// после чтения заказа из JSON, БД или кэша.
+func (o *Order) BindCurrency() {
+	c := o.Payment.Currency
+	p := &o.Payment
+	p.Amount.Currency = c
+	p.DeliveryCost.Currency = c
+	p.GoodsTotal.Currency = c
+	p.CustomFee.Currency = c
+	for i := range o.Items {
+		o.Items[i].Price.Currency = c
+		o.Items[i].TotalPrice.Currency = c
+	}
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyFormat(t *testing.T) {
+	cases := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(1817, "USD"), "18.17 USD"},
+		{NewMoney(5, "USD"), "0.05 USD"},
+		{NewMoney(-150, "EUR"), "-1.50 EUR"},
+		{NewMoney(1817, "JPY"), "1817 JPY"},
+		{NewMoney(1817, "KWD"), "1.817 KWD"},
+		{NewMoney(1817, ""), "18.17"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, c.money.Format())
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	sum, err := NewMoney(100, "USD").Add(NewMoney(23, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(123, "USD"), sum)
+
+	sum, err = NewMoney(100, "").Add(NewMoney(23, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(123, "USD"), sum)
+
+	_, err = NewMoney(100, "USD").Add(NewMoney(23, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoneyDiscount(t *testing.T) {
+	assert.Equal(t, NewMoney(317, "USD"), NewMoney(453, "USD").Discount(30))
+}
+
+func TestMoneyJSONKeepsWireFormat(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1817, "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `1817`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`453`), &m))
+	assert.Equal(t, int64(453), m.Minor)
+}
+
+func TestMarshalOrderFormatsAmounts(t *testing.T) {
+	order := sampleOrder()
+	order.Payment.Amount.Currency = ""
+
+	data, err := MarshalOrder(order)
+	require.NoError(t, err)
+
+	var out struct {
+		Payment struct {
+			Amount          int    `json:"amount"`
+			AmountFormatted string `json:"amount_formatted"`
+		} `json:"payment"`
+		Items []struct {
+			PriceFormatted string `json:"price_formatted"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, 1817, out.Payment.Amount)
+	assert.Equal(t, "18.17 USD", out.Payment.AmountFormatted)
+	assert.Equal(t, "4.53 USD", out.Items[0].PriceFormatted)
+	assert.Empty(t, order.Payment.Amount.Currency)
+
+	parsed, err := UnmarshalOrder(data)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(1817, "USD"), parsed.Payment.Amount)
+}
@@ -21,4 +21,17 @@ type Order struct {
 	SmID              int       `json:"sm_id" db:"sm_id"`
 	DateCreated       time.Time `json:"date_created" db:"date_created"`
 	OofShard          string    `json:"oof_shard" db:"oof_shard"`
+
+	// Результат валидации, с которым заказ был принят
+	ValidationStatus   ValidationStatus `json:"-" db:"validation_status"`
+	ValidationWarnings FieldErrors      `json:"-" db:"validation_warnings"`
+
+	// Version растёт на каждой записи заказа. Ненулевая версия во входящем
+	// заказе — ожидаемая текущая версия для Store (compare-and-set).
+	// Попадает в кэш, чтобы ETag из кэша совпадал с БД.
+	Version int `json:"version,omitempty" db:"version"`
 }
+
+// AnyVersion в Order.Version — заказ должен уже существовать, а его версия
+// не важна (If-Match: *)
+const AnyVersion = -1
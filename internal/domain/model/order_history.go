@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Источники изменений заказа
+const (
+	ChangeSourceKafka   = "kafka"
+	ChangeSourceHTTP    = "http"
+	ChangeSourceAdmin   = "admin"
+	ChangeSourceUnknown = "unknown"
+)
+
+// ChangeSource — кто записал заказ: вид источника и его идентификатор
+// (id сообщения Kafka, пользователь HTTP, имя администратора)
+type ChangeSource struct {
+	Kind string
+	ID   string
+}
+
+// OrderVersion — снимок заказа после одной записи через репозиторий
+type OrderVersion struct {
+	Version   int
+	Change    string // created или updated
+	Source    ChangeSource
+	ChangedAt time.Time
+	Order     Order
+}
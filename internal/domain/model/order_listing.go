@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderCursor — ключ заказа для постраничного обхода от новых к старым
+type OrderCursor struct {
+	DateCreated time.Time
+	OrderUID    uuid.UUID
+}
+
+// Cursor возвращает ключ заказа, после которого начнётся следующая страница
+func (o *Order) Cursor() *OrderCursor {
+	return &OrderCursor{DateCreated: o.DateCreated, OrderUID: o.OrderUID}
+}
+
+// OrderListing — страница заказов от новых к старым: не старше Since (нулевое
+// — без ограничения), после заказа After (nil — с самого нового), не больше
+// Limit штук
+type OrderListing struct {
+	Since time.Time
+	After *OrderCursor
+	Limit int
+}
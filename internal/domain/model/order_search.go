@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderSearch — запрос полнотекстового поиска по названиям и брендам
+// товаров и городу доставки. Query — слова в синтаксисе web-поиска:
+// все слова должны встретиться в заказе.
+type OrderSearch struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// OrderSearchHit — найденный заказ, Rank — релевантность (больше — выше)
+type OrderSearchHit struct {
+	OrderUID     uuid.UUID
+	TrackNumber  string
+	CustomerID   string
+	DateCreated  time.Time
+	DeliveryName string
+	DeliveryCity string
+	Rank         float64
+}
+
+// OrderSearchResult — страница результатов поиска и общее число совпадений
+type OrderSearchResult struct {
+	Total int
+	Hits  []OrderSearchHit
+}
@@ -7,14 +7,15 @@ import (
 )
 
 type Payment struct {
+	OrderUID     uuid.UUID `json:"-" db:"order_uid"`
 	Transaction  uuid.UUID `json:"transaction" db:"transaction"`
 	RequestID    string    `json:"request_id" db:"request_id"`
 	Currency     string    `json:"currency" db:"currency"`
 	Provider     string    `json:"provider" db:"provider"`
-	Amount       int       `json:"amount" db:"amount"`
+	Amount       Money     `json:"amount" db:"amount"`
 	PaymentDT    time.Time `json:"payment_dt" db:"payment_dt"`
 	Bank         string    `json:"bank" db:"bank"`
-	DeliveryCost int       `json:"delivery_cost" db:"delivery_cost"`
-	GoodsTotal   int       `json:"goods_total" db:"goods_total"`
-	CustomFee    int       `json:"custom_fee" db:"custom_fee"`
+	DeliveryCost Money     `json:"delivery_cost" db:"delivery_cost"`
+	GoodsTotal   Money     `json:"goods_total" db:"goods_total"`
+	CustomFee    Money     `json:"custom_fee" db:"custom_fee"`
 }
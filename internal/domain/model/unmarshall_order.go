@@ -28,22 +28,22 @@ func UnmarshalOrder(data []byte) (*Order, error) {
 			RequestID    string `json:"request_id"`
 			Currency     string `json:"currency"`
 			Provider     string `json:"provider"`
-			Amount       int    `json:"amount"`
+			Amount       Money  `json:"amount"`
 			PaymentDT    int64  `json:"payment_dt"`
 			Bank         string `json:"bank"`
-			DeliveryCost int    `json:"delivery_cost"`
-			GoodsTotal   int    `json:"goods_total"`
-			CustomFee    int    `json:"custom_fee"`
+			DeliveryCost Money  `json:"delivery_cost"`
+			GoodsTotal   Money  `json:"goods_total"`
+			CustomFee    Money  `json:"custom_fee"`
 		} `json:"payment"`
 		Items []struct {
 			ChrtID      int    `json:"chrt_id"`
 			TrackNumber string `json:"track_number"`
-			Price       int    `json:"price"`
+			Price       Money  `json:"price"`
 			Rid         string `json:"rid"`
 			Name        string `json:"name"`
 			Sale        int    `json:"sale"`
 			Size        string `json:"size"`
-			TotalPrice  int    `json:"total_price"`
+			TotalPrice  Money  `json:"total_price"`
 			NmID        int    `json:"nm_id"`
 			Brand       string `json:"brand"`
 			Status      int    `json:"status"`
@@ -106,6 +106,7 @@ func UnmarshalOrder(data []byte) (*Order, error) {
 			Email:    aux.Delivery.Email,
 		},
 		Payment: Payment{
+			OrderUID:     orderUID,
 			Transaction:  paymentTransaction,
 			RequestID:    aux.Payment.RequestID,
 			Currency:     aux.Payment.Currency,
@@ -137,5 +138,7 @@ func UnmarshalOrder(data []byte) (*Order, error) {
 		})
 	}
 
+	order.BindCurrency()
+
 	return order, nil
 }
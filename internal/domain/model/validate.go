@@ -0,0 +1,238 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Коды правил валидации
+const (
+	RuleRequired      = "required"
+	RuleNonNegative   = "non_negative"
+	RuleItemTotal     = "item_total"
+	RuleGoodsTotal    = "goods_total"
+	RulePaymentAmount = "payment_amount"
+	RuleCurrency      = "currency"
+	RuleLocale        = "locale"
+	RuleEmail         = "email"
+	RulePhone         = "phone"
+	RuleDateCreated   = "date_created"
+)
+
+// RuleUniqueRid — rid повторяется в заказе. rid — естественный ключ товара,
+// такой заказ сохранить нельзя, поэтому правило не настраивается политикой.
+const RuleUniqueRid = "unique_rid"
+
+// Допустимое расхождение часов продюсера и сервиса для date_created
+const maxClockSkew = time.Hour
+
+var (
+	localeRe = regexp.MustCompile(`^[a-z]{2}([-_][A-Z]{2})?$`)
+	phoneRe  = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	minDate  = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Rules — коды правил, severity которых задаётся политикой
+var Rules = []string{
+	RuleRequired, RuleNonNegative, RuleItemTotal, RuleGoodsTotal, RulePaymentAmount,
+	RuleCurrency, RuleLocale, RuleEmail, RulePhone, RuleDateCreated,
+}
+
+// ValidationStatus — с каким результатом валидации заказ был принят
+type ValidationStatus string
+
+const (
+	ValidationValid       ValidationStatus = "valid"
+	ValidationWarned      ValidationStatus = "warned"
+	ValidationQuarantined ValidationStatus = "quarantined"
+)
+
+// FieldError — нарушение одного правила для конкретного поля
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// FieldErrors хранится в БД как JSONB
+type FieldErrors []FieldError
+
+func (f FieldErrors) Value() (driver.Value, error) {
+	if len(f) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *FieldErrors) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return fmt.Errorf("unsupported type for FieldErrors: %T", src)
+	}
+}
+
+// ValidationError содержит все нарушения, найденные в заказе
+type ValidationError struct {
+	Errors FieldErrors
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "invalid order: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, rule, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (e *ValidationError) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		e.add(field, RuleRequired, "must not be empty")
+	}
+}
+
+func (e *ValidationError) nonNegative(field string, value Money) {
+	if value.IsNegative() {
+		e.add(field, RuleNonNegative, "must not be negative, got %s", value)
+	}
+}
+
+// Validate проверяет заказ перед сохранением. Возвращает *ValidationError
+// со всеми нарушениями или nil.
+func (o *Order) Validate() error {
+	v := &ValidationError{}
+
+	if o.OrderUID == uuid.Nil {
+		v.add("order_uid", RuleRequired, "must not be empty")
+	}
+	v.required("track_number", o.TrackNumber)
+	v.required("entry", o.Entry)
+	v.required("customer_id", o.CustomerID)
+	v.required("delivery_service", o.DeliveryService)
+
+	if !localeRe.MatchString(o.Locale) {
+		v.add("locale", RuleLocale, "must be a language code like \"en\" or \"en-US\", got %q", o.Locale)
+	}
+
+	switch {
+	case o.DateCreated.IsZero():
+		v.add("date_created", RuleRequired, "must not be empty")
+	case o.DateCreated.Before(minDate):
+		v.add("date_created", RuleDateCreated, "is too far in the past: %s", o.DateCreated.Format(time.RFC3339))
+	case o.DateCreated.After(time.Now().Add(maxClockSkew)):
+		v.add("date_created", RuleDateCreated, "is in the future: %s", o.DateCreated.Format(time.RFC3339))
+	}
+
+	o.Delivery.validate(v)
+	o.Payment.validate(v)
+
+	if len(o.Items) == 0 {
+		v.add("items", RuleRequired, "order must contain at least one item")
+	}
+	totals := make([]Money, 0, len(o.Items))
+	rids := make(map[string]int, len(o.Items))
+	for i := range o.Items {
+		prefix := fmt.Sprintf("items[%d]", i)
+		o.Items[i].validate(v, prefix)
+		totals = append(totals, o.Items[i].TotalPrice)
+
+		if first, ok := rids[o.Items[i].Rid]; ok && o.Items[i].Rid != "" {
+			v.add(prefix+".rid", RuleUniqueRid, "duplicates items[%d].rid %q", first, o.Items[i].Rid)
+			continue
+		}
+		rids[o.Items[i].Rid] = i
+	}
+
+	goodsTotal, err := SumMoney(totals...)
+	switch {
+	case err != nil:
+		v.add("items", RuleCurrency, "item prices must share the payment currency: %v", err)
+	case len(o.Items) > 0 && o.Payment.GoodsTotal.Minor != goodsTotal.Minor:
+		v.add("payment.goods_total", RuleGoodsTotal,
+			"must equal sum of items total_price (%s), got %s", goodsTotal, o.Payment.GoodsTotal)
+	}
+
+	if len(v.Errors) == 0 {
+		return nil
+	}
+	return v
+}
+
+func (d *Delivery) validate(v *ValidationError) {
+	v.required("delivery.name", d.Name)
+	v.required("delivery.city", d.City)
+	v.required("delivery.address", d.Address)
+
+	if !phoneRe.MatchString(d.Phone) {
+		v.add("delivery.phone", RulePhone, "must be 7-15 digits with optional leading +, got %q", d.Phone)
+	}
+
+	if addr, err := mail.ParseAddress(d.Email); err != nil || addr.Address != d.Email {
+		v.add("delivery.email", RuleEmail, "must be a valid email address, got %q", d.Email)
+	}
+}
+
+func (p *Payment) validate(v *ValidationError) {
+	if p.Transaction == uuid.Nil {
+		v.add("payment.transaction", RuleRequired, "must not be empty")
+	}
+	v.required("payment.provider", p.Provider)
+
+	if !IsCurrency(p.Currency) {
+		v.add("payment.currency", RuleCurrency, "must be an ISO 4217 code, got %q", p.Currency)
+	}
+
+	v.nonNegative("payment.amount", p.Amount)
+	v.nonNegative("payment.delivery_cost", p.DeliveryCost)
+	v.nonNegative("payment.goods_total", p.GoodsTotal)
+	v.nonNegative("payment.custom_fee", p.CustomFee)
+
+	expected, err := SumMoney(p.GoodsTotal, p.DeliveryCost, p.CustomFee)
+	switch {
+	case err != nil:
+		v.add("payment", RuleCurrency, "amounts must share the payment currency: %v", err)
+	case p.Amount.Minor != expected.Minor:
+		v.add("payment.amount", RulePaymentAmount,
+			"must equal goods_total + delivery_cost + custom_fee (%s), got %s", expected, p.Amount)
+	}
+}
+
+func (it *Item) validate(v *ValidationError, prefix string) {
+	v.required(prefix+".rid", it.Rid)
+	v.required(prefix+".name", it.Name)
+	v.required(prefix+".track_number", it.TrackNumber)
+
+	v.nonNegative(prefix+".price", it.Price)
+	v.nonNegative(prefix+".total_price", it.TotalPrice)
+	if it.Sale < 0 || it.Sale > 100 {
+		v.add(prefix+".sale", RuleNonNegative, "must be a percentage between 0 and 100, got %d", it.Sale)
+		return
+	}
+
+	// total_price — цена со скидкой sale (в процентах), округлённая вниз
+	if expected := it.Price.Discount(it.Sale); it.TotalPrice.Minor != expected.Minor {
+		v.add(prefix+".total_price", RuleItemTotal,
+			"must equal price discounted by sale (%s), got %s", expected, it.TotalPrice)
+	}
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleOrder() *Order {
+	uid := uuid.New()
+	return &Order{
+		OrderUID:        uid,
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		DateCreated:     time.Now().Add(-time.Hour),
+		Delivery: Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Email:   "test@gmail.com",
+		},
+		Payment: Payment{
+			Transaction:  uid,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       NewMoney(1817, "USD"),
+			DeliveryCost: NewMoney(1500, "USD"),
+			GoodsTotal:   NewMoney(317, "USD"),
+		},
+		Items: []Item{{
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       NewMoney(453, "USD"),
+			Rid:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			TotalPrice:  NewMoney(317, "USD"),
+		}},
+	}
+}
+
+func fieldRules(err error) map[string]string {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	res := make(map[string]string)
+	for _, fe := range verr.Errors {
+		res[fe.Field] = fe.Rule
+	}
+	return res
+}
+
+func TestValidate_ValidOrder(t *testing.T) {
+	assert.NoError(t, sampleOrder().Validate())
+}
+
+func TestValidate_FieldErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(o *Order)
+		field  string
+		rule   string
+	}{
+		{"empty track number", func(o *Order) { o.TrackNumber = "" }, "track_number", RuleRequired},
+		{"negative price", func(o *Order) { o.Items[0].Price.Minor = -1 }, "items[0].price", RuleNonNegative},
+		{"item total mismatch", func(o *Order) { o.Items[0].TotalPrice.Minor = 453 }, "items[0].total_price", RuleItemTotal},
+		{"goods total mismatch", func(o *Order) { o.Payment.GoodsTotal.Minor = 300; o.Payment.Amount.Minor = 1800 }, "payment.goods_total", RuleGoodsTotal},
+		{"amount mismatch", func(o *Order) { o.Payment.Amount.Minor = 317 }, "payment.amount", RulePaymentAmount},
+		{"unknown currency", func(o *Order) { o.Payment.Currency = "usd" }, "payment.currency", RuleCurrency},
+		{"bad locale", func(o *Order) { o.Locale = "english" }, "locale", RuleLocale},
+		{"bad email", func(o *Order) { o.Delivery.Email = "test@" }, "delivery.email", RuleEmail},
+		{"bad phone", func(o *Order) { o.Delivery.Phone = "call me" }, "delivery.phone", RulePhone},
+		{"future date", func(o *Order) { o.DateCreated = time.Now().Add(48 * time.Hour) }, "date_created", RuleDateCreated},
+		{"no items", func(o *Order) { o.Items = nil }, "items", RuleRequired},
+		{"duplicate rid", func(o *Order) { o.Items = append(o.Items, o.Items[0]) }, "items[1].rid", RuleUniqueRid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := sampleOrder()
+			tt.mutate(o)
+
+			rules := fieldRules(o.Validate())
+			assert.Equal(t, tt.rule, rules[tt.field], "errors: %v", rules)
+		})
+	}
+}
@@ -1,7 +1,14 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/application"
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
@@ -12,8 +19,17 @@ import (
 
 type Handler interface {
 	GetOrder(c *gin.Context)
+	SaveOrder(c *gin.Context)
+	GetOrderHistory(c *gin.Context)
+	SearchOrders(c *gin.Context)
 }
 
+// userHeader — заголовок с пользователем HTTP API для истории изменений.
+// Сервис не аутентифицирует клиентов, поэтому значение не проверяется и
+// пишется в историю как заявленное клиентом: это подсказка для разбора, а не
+// доказательство авторства. Проверять его должен шлюз перед сервисом.
+const userHeader = "X-User-ID"
+
 type handler struct {
 	service application.OrdersService
 }
@@ -29,24 +45,336 @@ func NewHandler(service application.OrdersService) Handler {
 // @Tags orders
 // @Produce json
 // @Param order_uid path string true "Order UID"
+// @Param as_of query string false "Вернуть заказ таким, каким он был в этот момент (RFC 3339)"
 // @Success 200 {object} dto.Order "Успешный ответ"
+// @Header 200 {string} ETag "Версия заказа (без as_of)"
+// @Failure 400 {object} dto.ErrorResponse "Некорректный as_of"
 // @Failure 404 {object} dto.ErrorResponse "Заказ не найден"
+// @Failure 500 {object} dto.ErrorResponse "Ошибка чтения заказа"
 // @Router /order/{order_uid} [get]
 func (h *handler) GetOrder(c *gin.Context) {
 	id := c.Param("id")
 	logger.Log.Infof("GetOrder: getting order %s", id)
 
-	order, err := h.service.GetOrder(id)
+	var (
+		order model.Order
+		err   error
+	)
+	asOf := c.Query("as_of")
+	if asOf != "" {
+		at, perr := time.Parse(time.RFC3339, asOf)
+		if perr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "as_of must be RFC 3339 timestamp"})
+			return
+		}
+		order, err = h.service.GetOrderAsOf(c.Request.Context(), id, at)
+	} else {
+		order, err = h.service.GetOrder(c.Request.Context(), id)
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "order not found"})
+		if errors.Is(err, application.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "order not found"})
+			return
+		}
+		logger.Log.Errorf("GetOrder: failed to get order %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get order"})
 		return
 	}
 
 	resp, err := model.MarshalOrder(&order)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// ETag — текущая версия; у исторического снимка её нет
+	if asOf == "" && order.Version > 0 {
+		c.Header("ETag", etag(order.Version))
 	}
 
 	logger.Log.Infof("GetOrder: found order %s", id)
 	c.Data(http.StatusOK, "application/json", resp)
 }
+
+// @Summary Сохранить заказ
+// @Description Проверяет заказ и сохраняет его в БД и кэш
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body dto.Order true "Заказ"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет, список ETag через запятую или * — заказ должен существовать"
+// @Param X-User-ID header string false "Кто меняет заказ, для истории. Не проверяется сервисом"
+// @Success 200 {object} dto.Order "Заказ уже был сохранён: обновлён или не изменился"
+// @Success 201 {object} dto.Order "Заказ создан"
+// @Header 200,201 {string} ETag "Версия заказа после записи"
+// @Failure 400 {object} dto.ErrorResponse "Некорректный JSON или If-Match"
+// @Failure 409 {object} dto.ErrorResponse "Заказ уже сохранён, а хранилище не обновляет заказы, или заказ в архиве"
+// @Failure 412 {object} dto.ErrorResponse "Версия заказа не совпала с If-Match или заказа нет"
+// @Failure 422 {object} dto.ValidationErrorResponse "Заказ не прошёл валидацию"
+// @Failure 500 {object} dto.ErrorResponse "Ошибка сохранения"
+// @Router /order [post]
+func (h *handler) SaveOrder(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	order, err := model.UnmarshalOrder(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var versions []int
+	if header := c.GetHeader("If-Match"); header != "" {
+		cond, err := parseIfMatch(header)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "If-Match must be * or a list of ETags"})
+			return
+		}
+		versions = cond.versions
+		if cond.any {
+			versions = []int{model.AnyVersion}
+		}
+		if len(versions) == 0 {
+			// Ни один ETag не может сильно совпасть с версией заказа
+			c.JSON(http.StatusPreconditionFailed, dto.ErrorResponse{Error: "order version does not match If-Match"})
+			return
+		}
+	}
+
+	src := model.ChangeSource{Kind: model.ChangeSourceHTTP, ID: c.GetHeader(userHeader)}
+	if src.ID == "" {
+		src.ID = c.ClientIP()
+	}
+	ctx := application.WithChangeSource(c.Request.Context(), src)
+
+	result, err := h.saveOrder(ctx, order, versions)
+	if err != nil {
+		var verr *model.ValidationError
+		if errors.As(err, &verr) {
+			c.JSON(http.StatusUnprocessableEntity, validationErrorResponse(verr))
+			return
+		}
+		if errors.Is(err, application.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, dto.ErrorResponse{Error: "order version does not match If-Match"})
+			return
+		}
+		if errors.Is(err, application.ErrOrderExists) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "order already exists and cannot be updated"})
+			return
+		}
+		if errors.Is(err, application.ErrOrderArchived) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "order is archived and cannot be updated"})
+			return
+		}
+		logger.Log.Errorf("SaveOrder: failed to save order %s: %v", order.OrderUID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to save order"})
+		return
+	}
+
+	resp, err := model.MarshalOrder(order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logger.Log.Infof("SaveOrder: order %s %s", order.OrderUID, result)
+	status := http.StatusOK
+	if result == application.StoreCreated {
+		status = http.StatusCreated
+	}
+	c.Header("ETag", etag(order.Version))
+	c.Data(status, "application/json", resp)
+}
+
+// saveOrder сохраняет заказ, если его текущая версия — одна из versions;
+// пустой список — без условия. Store сверяет одну версию, поэтому версии
+// пробуются по очереди до первой совпавшей.
+func (h *handler) saveOrder(ctx context.Context, order *model.Order, versions []int) (application.StoreResult, error) {
+	if len(versions) == 0 {
+		return h.service.SaveOrder(ctx, order)
+	}
+
+	var err error
+	for _, version := range versions {
+		order.Version = version
+		var result application.StoreResult
+		result, err = h.service.SaveOrder(ctx, order)
+		if !errors.Is(err, application.ErrVersionConflict) {
+			return result, err
+		}
+	}
+	return "", err
+}
+
+// @Summary История изменений заказа
+// @Description Возвращает все версии заказа от старой к новой с источником каждой записи
+// @Tags orders
+// @Produce json
+// @Param order_uid path string true "Order UID"
+// @Success 200 {object} dto.OrderHistoryResponse "Успешный ответ"
+// @Failure 404 {object} dto.ErrorResponse "Заказ не найден"
+// @Failure 500 {object} dto.ErrorResponse "Ошибка чтения истории"
+// @Router /order/{order_uid}/history [get]
+func (h *handler) GetOrderHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	versions, err := h.service.GetOrderHistory(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, application.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "order not found"})
+			return
+		}
+		logger.Log.Errorf("GetOrderHistory: failed to get history of order %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get order history"})
+		return
+	}
+
+	resp := dto.OrderHistoryResponse{OrderUID: id}
+	for _, v := range versions {
+		order, err := model.MarshalOrder(&v.Order)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		resp.Versions = append(resp.Versions, dto.OrderVersion{
+			Version:   v.Version,
+			Change:    v.Change,
+			Source:    v.Source.Kind,
+			SourceID:  v.Source.ID,
+			ChangedAt: v.ChangedAt,
+			Order:     order,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Поиск заказов
+// @Description Полнотекстовый поиск по названиям и брендам товаров и городу доставки. Все слова запроса должны встретиться в заказе; поддерживаются "фразы в кавычках", -исключение и or. Лучшие совпадения первыми.
+// @Tags orders
+// @Produce json
+// @Param q query string true "Поисковый запрос" example(Vivienne Sabo mascara Haifa)
+// @Param limit query int false "Размер страницы (по умолчанию 20, не больше 100)"
+// @Param offset query int false "Сколько совпадений пропустить"
+// @Success 200 {object} dto.OrderSearchResponse "Успешный ответ"
+// @Failure 400 {object} dto.ErrorResponse "Пустой запрос или некорректные limit/offset"
+// @Failure 500 {object} dto.ErrorResponse "Ошибка поиска"
+// @Router /orders/search [get]
+func (h *handler) SearchOrders(c *gin.Context) {
+	search := model.OrderSearch{Query: c.Query("q")}
+	var err error
+	if search.Limit, err = queryInt(c, "limit"); err != nil || search.Limit < 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "limit must be a non-negative integer"})
+		return
+	}
+	if search.Offset, err = queryInt(c, "offset"); err != nil || search.Offset < 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "offset must be a non-negative integer"})
+		return
+	}
+
+	result, err := h.service.SearchOrders(c.Request.Context(), search)
+	if err != nil {
+		if errors.Is(err, application.ErrEmptySearchQuery) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "q must not be empty"})
+			return
+		}
+		logger.Log.Errorf("SearchOrders: failed to search %q: %v", search.Query, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to search orders"})
+		return
+	}
+
+	resp := dto.OrderSearchResponse{
+		Total: result.Total,
+		Hits:  make([]dto.OrderSearchHit, 0, len(result.Hits)),
+	}
+	for _, hit := range result.Hits {
+		resp.Hits = append(resp.Hits, dto.OrderSearchHit{
+			OrderUID:     hit.OrderUID.String(),
+			TrackNumber:  hit.TrackNumber,
+			CustomerID:   hit.CustomerID,
+			DateCreated:  hit.DateCreated,
+			DeliveryName: hit.DeliveryName,
+			DeliveryCity: hit.DeliveryCity,
+			Rank:         hit.Rank,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// queryInt читает целый query-параметр, отсутствующий — 0
+func queryInt(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func validationErrorResponse(verr *model.ValidationError) dto.ValidationErrorResponse {
+	resp := dto.ValidationErrorResponse{Error: "order validation failed"}
+	for _, fe := range verr.Errors {
+		resp.Fields = append(resp.Fields, dto.FieldError{
+			Field:   fe.Field,
+			Rule:    fe.Rule,
+			Message: fe.Message,
+		})
+	}
+	return resp
+}
+
+// etag — сильный ETag версии заказа: "3"
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatch — разобранный заголовок If-Match
+type ifMatch struct {
+	any      bool  // "*": заказ должен существовать
+	versions []int // версии из сильных ETag списка
+}
+
+var errInvalidIfMatch = errors.New("invalid If-Match")
+
+// parseIfMatch разбирает If-Match по RFC 9110: "*" или список ETag через
+// запятую. If-Match сравнивает ETag сильно, поэтому слабые (W/"3") не
+// совпадают ни с одной версией и отбрасываются, как и ETag не нашего вида.
+func parseIfMatch(value string) (ifMatch, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return ifMatch{any: true}, nil
+	}
+
+	var cond ifMatch
+	for rest := value; ; {
+		// Пустые элементы списка допустимы и пропускаются
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return cond, nil
+		}
+
+		weak := strings.HasPrefix(rest, "W/")
+		if weak {
+			rest = rest[len("W/"):]
+		}
+		if !strings.HasPrefix(rest, `"`) {
+			return ifMatch{}, errInvalidIfMatch
+		}
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return ifMatch{}, errInvalidIfMatch
+		}
+		tag := rest[1 : end+1]
+		rest = strings.TrimLeft(rest[end+2:], " \t")
+		if rest != "" && rest[0] != ',' {
+			return ifMatch{}, errInvalidIfMatch
+		}
+
+		if version, err := strconv.Atoi(tag); !weak && err == nil && version > 0 && !slices.Contains(cond.versions, version) {
+			cond.versions = append(cond.versions, version)
+		}
+	}
+}
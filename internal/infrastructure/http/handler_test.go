@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/memory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	for value, want := range map[string]ifMatch{
+		`*`:                 {any: true},
+		` "3" `:             {versions: []int{3}},
+		`"3", "5"`:          {versions: []int{3, 5}},
+		`"3",,"3" ,`:        {versions: []int{3}},
+		`W/"3"`:             {},
+		`W/"3", "4"`:        {versions: []int{4}},
+		`"abc", "a,b", "0"`: {},
+	} {
+		got, err := parseIfMatch(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	for _, value := range []string{`3`, `"3`, `"3" "4"`, `*, "3"`, `W/3`} {
+		_, err := parseIfMatch(value)
+		assert.ErrorIs(t, err, errInvalidIfMatch, value)
+	}
+}
+
+func TestSaveOrder_IfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	repo := memory.NewOrdersRepository()
+	router := gin.New()
+	RegisterRoutes(router, NewHandler(application.NewOrdersService(memory.NewNopCache(), repo, nil, nil)))
+
+	order := contract.NewOrder()
+	post := func(ifMatch string) int {
+		body, err := model.MarshalOrder(order)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// "*" требует, чтобы заказ уже был
+	assert.Equal(t, http.StatusPreconditionFailed, post(`*`))
+	_, err := repo.Store(ctx, order)
+	require.NoError(t, err)
+
+	order.TrackNumber = "WBILMANY"
+	assert.Equal(t, http.StatusOK, post(`*`))
+
+	// Слабый ETag при сильном сравнении не совпадает
+	order.TrackNumber = "WBILMWEAK"
+	assert.Equal(t, http.StatusPreconditionFailed, post(`W/"2"`))
+	assert.Equal(t, http.StatusBadRequest, post(`2`))
+
+	// Достаточно совпадения с одним ETag списка
+	assert.Equal(t, http.StatusOK, post(`"9", "2"`))
+	got, err := repo.Get(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "WBILMWEAK", got.TrackNumber)
+	assert.Equal(t, 3, got.Version)
+}
+
+// storeErrRepository отвечает на Store заданной ошибкой
+type storeErrRepository struct {
+	application.OrdersRepository
+	err error
+}
+
+func (r storeErrRepository) Store(context.Context, *model.Order) (application.StoreResult, error) {
+	return "", r.err
+}
+
+func TestSaveOrder_Conflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, storeErr := range []error{application.ErrOrderExists, application.ErrOrderArchived} {
+		repo := storeErrRepository{OrdersRepository: memory.NewOrdersRepository(), err: storeErr}
+		router := gin.New()
+		RegisterRoutes(router, NewHandler(application.NewOrdersService(memory.NewNopCache(), repo, nil, nil)))
+
+		body, err := model.MarshalOrder(contract.NewOrder())
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusConflict, rec.Code, storeErr)
+	}
+}
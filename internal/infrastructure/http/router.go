@@ -6,5 +6,9 @@ func RegisterRoutes(r *gin.Engine, handler Handler) {
 	s := r.Group("/order")
 	{
 		s.GET("/:id", handler.GetOrder)
+		s.GET("/:id/history", handler.GetOrderHistory)
+		s.POST("", handler.SaveOrder)
 	}
+
+	r.GET("/orders/search", handler.SearchOrders)
 }
@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+)
+
+var (
+	backlogPending   = new(expvar.Int)
+	backlogOldestAge = new(expvar.Float)
+	consumerPaused   = new(expvar.Int)
+	consumerPauses   = new(expvar.Int)
+)
+
+func init() {
+	inboxMetrics.Set("backlog_pending", backlogPending)
+	inboxMetrics.Set("backlog_oldest_age_seconds", backlogOldestAge)
+	inboxMetrics.Set("consumer_paused", consumerPaused)
+	inboxMetrics.Set("consumer_pauses_total", consumerPauses)
+}
+
+// backpressure останавливает чтение из Kafka, пока inbox не разгрузится.
+// Пауза включается при превышении любого из порогов Max*, а снимается, только
+// когда backlog опустился ниже порогов Resume* (по умолчанию — половина Max*).
+type backpressure struct {
+	cfg  config.BackpressureConfig
+	repo application.OrdersRepository
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{} // закрыт, пока чтение разрешено
+}
+
+func newBackpressure(cfg config.BackpressureConfig, repo application.OrdersRepository) *backpressure {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 5 * time.Second
+	}
+	if cfg.ResumePending <= 0 {
+		cfg.ResumePending = cfg.MaxPending / 2
+	}
+	if cfg.ResumeOldestAge <= 0 {
+		cfg.ResumeOldestAge = cfg.MaxOldestAge / 2
+	}
+
+	resumed := make(chan struct{})
+	close(resumed)
+
+	return &backpressure{
+		cfg:     cfg,
+		repo:    repo,
+		resumed: resumed,
+	}
+}
+
+func (b *backpressure) start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(b.cfg.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				backlog, err := b.repo.InboxBacklog(ctx)
+				if err != nil {
+					logger.Log.Errorf("failed to check inbox backlog: %v", err)
+					continue
+				}
+				b.update(backlog)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (b *backpressure) update(backlog model.InboxBacklog) {
+	backlogPending.Set(int64(backlog.Pending))
+	backlogOldestAge.Set(backlog.OldestAge.Seconds())
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case !b.paused && b.overloaded(backlog):
+		b.paused = true
+		b.resumed = make(chan struct{})
+		consumerPaused.Set(1)
+		consumerPauses.Add(1)
+		logger.Log.Warnf(
+			"inbox backlog too large (pending=%d, oldest=%s), pausing consumer",
+			backlog.Pending, backlog.OldestAge,
+		)
+	case b.paused && b.drained(backlog):
+		b.paused = false
+		close(b.resumed)
+		consumerPaused.Set(0)
+		logger.Log.Infof(
+			"inbox backlog drained (pending=%d, oldest=%s), resuming consumer",
+			backlog.Pending, backlog.OldestAge,
+		)
+	}
+}
+
+func (b *backpressure) overloaded(backlog model.InboxBacklog) bool {
+	return (b.cfg.MaxPending > 0 && backlog.Pending >= b.cfg.MaxPending) ||
+		(b.cfg.MaxOldestAge > 0 && backlog.OldestAge >= b.cfg.MaxOldestAge)
+}
+
+func (b *backpressure) drained(backlog model.InboxBacklog) bool {
+	return (b.cfg.MaxPending <= 0 || backlog.Pending <= b.cfg.ResumePending) &&
+		(b.cfg.MaxOldestAge <= 0 || backlog.OldestAge <= b.cfg.ResumeOldestAge)
+}
+
+// wait блокируется, пока консьюмер на паузе
+func (b *backpressure) wait(ctx context.Context) error {
+	b.mu.Lock()
+	resumed := b.resumed
+	b.mu.Unlock()
+
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackpressure_PauseAndResumeWithHysteresis(t *testing.T) {
+	var cfg config.BackpressureConfig
+	cfg.MaxPending = 100
+	cfg.MaxOldestAge = time.Minute
+	b := newBackpressure(cfg, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	b.update(model.InboxBacklog{Pending: 100})
+	assert.Error(t, b.wait(ctx), "consumer should be paused")
+
+	// ниже Max, но выше Resume — пауза сохраняется
+	b.update(model.InboxBacklog{Pending: 70})
+	assert.True(t, b.paused)
+
+	b.update(model.InboxBacklog{Pending: 50, OldestAge: 10 * time.Second})
+	assert.NoError(t, b.wait(context.Background()))
+
+	b.update(model.InboxBacklog{Pending: 1, OldestAge: 2 * time.Minute})
+	assert.True(t, b.paused)
+}
@@ -2,50 +2,71 @@ package kafka
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
 	"github.com/Babushkin05/wb-orders-service/pkg/logger"
 	"github.com/segmentio/kafka-go"
 )
 
-type kafkaConfig struct {
-	Broker  string `yaml:"broker"`
-	Topic   string `yaml:"topic"`
-	GroupID string `yaml:"group_id"`
-}
-
 type InboxConsumer interface {
 	Start(ctx context.Context)
 }
 
 type inboxConsumer struct {
-	reader *kafka.Reader
-	repo   application.OrdersRepository
+	reader       *kafka.Reader
+	repo         application.OrdersRepository
+	backpressure *backpressure
 }
 
-func NewInboxConsumer(cfg kafkaConfig, repo application.OrdersRepository) InboxConsumer {
+func NewInboxConsumer(cfg config.KafkaConfig, repo application.OrdersRepository) InboxConsumer {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{cfg.Broker},
 		Topic:   cfg.Topic,
 		GroupID: cfg.GroupID,
 	})
 
-	return &inboxConsumer{
+	c := &inboxConsumer{
 		reader: reader,
 		repo:   repo,
 	}
+	if cfg.Backpressure.Enabled {
+		c.backpressure = newBackpressure(cfg.Backpressure, repo)
+	}
+
+	return c
 }
 
 func (c *inboxConsumer) Start(ctx context.Context) {
+	if c.backpressure != nil {
+		c.backpressure.start(ctx)
+	}
+
 	go func() {
 		for {
+			if c.backpressure != nil {
+				if err := c.backpressure.wait(ctx); err != nil {
+					return
+				}
+			}
+
 			m, err := c.reader.ReadMessage(ctx)
 			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				logger.Log.Errorf("inbox consumer read error: %v", err)
 				continue
 			}
 
-			err = c.repo.SaveInboxMessage(ctx, string(m.Key), m.Topic, string(m.Value))
+			err = c.repo.SaveInboxMessage(ctx, model.InboxMessage{
+				ID:      fmt.Sprintf("%s/%d/%d", m.Topic, m.Partition, m.Offset),
+				Key:     string(m.Key),
+				Topic:   m.Topic,
+				Payload: string(m.Value),
+			})
 			if err != nil {
 				logger.Log.Errorf("failed to save inbox message: %v", err)
 			}
@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+)
+
+type InboxJanitor interface {
+	Start(ctx context.Context)
+}
+
+// inboxJanitor удаляет (или переносит в inbox_archive) обработанные
+// сообщения старше окна хранения. Dead-letter хранятся отдельно и дольше.
+type inboxJanitor struct {
+	cfg  config.RetentionConfig
+	repo application.OrdersRepository
+}
+
+func NewInboxJanitor(cfg config.RetentionConfig, repo application.OrdersRepository) InboxJanitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+
+	return &inboxJanitor{
+		cfg:  cfg,
+		repo: repo,
+	}
+}
+
+func (j *inboxJanitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			j.run(ctx)
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("inbox janitor stopped")
+				return
+			}
+		}
+	}()
+}
+
+func (j *inboxJanitor) run(ctx context.Context) {
+	if j.cfg.ProcessedTTL > 0 {
+		j.purge(ctx, false, j.cfg.ProcessedTTL)
+	}
+	if j.cfg.DeadLetterTTL > 0 {
+		j.purge(ctx, true, j.cfg.DeadLetterTTL)
+	}
+}
+
+// purge чистит inbox небольшими пачками, пока есть что удалять
+func (j *inboxJanitor) purge(ctx context.Context, deadLettered bool, ttl time.Duration) {
+	opts := model.InboxPurge{
+		DeadLettered: deadLettered,
+		OlderThan:    ttl,
+		Limit:        j.cfg.BatchSize,
+		Archive:      j.cfg.Archive,
+	}
+
+	total := 0
+	for ctx.Err() == nil {
+		n, err := j.repo.PurgeInboxMessages(ctx, opts)
+		if err != nil {
+			logger.Log.Errorf("inbox janitor purge error: %v", err)
+			break
+		}
+		total += n
+		if n < opts.Limit {
+			break
+		}
+
+		select {
+		case <-time.After(j.cfg.PauseBetweenBatches):
+		case <-ctx.Done():
+		}
+	}
+
+	if total > 0 {
+		logger.Log.Infof("inbox janitor: purged %d messages (dead_lettered=%t, archive=%t)", total, deadLettered, j.cfg.Archive)
+	}
+}
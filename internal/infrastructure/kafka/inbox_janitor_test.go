@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// purgeRecorder запоминает вызовы PurgeInboxMessages и отвечает заданным
+// числом удалённых сообщений
+type purgeRecorder struct {
+	application.OrdersRepository
+	calls   []model.InboxPurge
+	results []int
+}
+
+func (r *purgeRecorder) PurgeInboxMessages(_ context.Context, opts model.InboxPurge) (int, error) {
+	r.calls = append(r.calls, opts)
+	if len(r.results) == 0 {
+		return 0, nil
+	}
+	n := r.results[0]
+	r.results = r.results[1:]
+	return n, nil
+}
+
+func TestInboxJanitor_SeparateTTLs(t *testing.T) {
+	repo := &purgeRecorder{}
+	j := NewInboxJanitor(config.RetentionConfig{
+		ProcessedTTL:  time.Hour,
+		DeadLetterTTL: 24 * time.Hour,
+		BatchSize:     10,
+		Archive:       true,
+	}, repo).(*inboxJanitor)
+
+	j.run(context.Background())
+
+	assert.Equal(t, []model.InboxPurge{
+		{DeadLettered: false, OlderThan: time.Hour, Limit: 10, Archive: true},
+		{DeadLettered: true, OlderThan: 24 * time.Hour, Limit: 10, Archive: true},
+	}, repo.calls)
+}
+
+func TestInboxJanitor_ZeroTTLDisablesPurge(t *testing.T) {
+	repo := &purgeRecorder{}
+	j := NewInboxJanitor(config.RetentionConfig{DeadLetterTTL: time.Hour}, repo).(*inboxJanitor)
+
+	j.run(context.Background())
+
+	if assert.Len(t, repo.calls, 1) {
+		assert.True(t, repo.calls[0].DeadLettered)
+	}
+}
+
+func TestInboxJanitor_PurgesInBatchesUntilShortBatch(t *testing.T) {
+	repo := &purgeRecorder{results: []int{5, 5, 2}}
+	j := NewInboxJanitor(config.RetentionConfig{ProcessedTTL: time.Hour, BatchSize: 5}, repo).(*inboxJanitor)
+
+	j.purge(context.Background(), false, time.Hour)
+
+	assert.Len(t, repo.calls, 3)
+}
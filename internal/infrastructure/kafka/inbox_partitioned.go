@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"context"
+	"expvar"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+)
+
+type inboxJob struct {
+	msg    model.InboxMessage
+	wg     *sync.WaitGroup
+	failed *failedKeys
+}
+
+// failedKeys — ключи, по которым в текущей пачке уже была ошибка.
+// Следующие сообщения с тем же ключом пропускаются, чтобы не нарушить порядок.
+type failedKeys struct {
+	mu   sync.Mutex
+	keys map[string]error
+}
+
+func (f *failedKeys) add(key string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[key] = err
+}
+
+func (f *failedKeys) has(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.keys[key]
+	return ok
+}
+
+// first возвращает число упавших ключей и одну из их ошибок
+func (f *failedKeys) first() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, err := range f.keys {
+		return len(f.keys), err
+	}
+	return 0, nil
+}
+
+// partitionedDispatcher раскладывает сообщения по воркерам по хэшу ключа.
+// Сообщения с одним ключом всегда попадают в одну очередь и обрабатываются
+// в порядке created_at, разные ключи — параллельно.
+type partitionedDispatcher struct {
+	queues []chan inboxJob
+	depth  []atomic.Int64
+}
+
+func newPartitionedDispatcher(workers, batchSize int) *partitionedDispatcher {
+	d := &partitionedDispatcher{
+		queues: make([]chan inboxJob, workers),
+		depth:  make([]atomic.Int64, workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan inboxJob, batchSize)
+	}
+
+	inboxMetrics.Set("worker_queue_depth", expvar.Func(func() any {
+		return d.queueDepths()
+	}))
+
+	return d
+}
+
+func (d *partitionedDispatcher) start(ctx context.Context, process func(context.Context, model.InboxMessage) error) {
+	for i := range d.queues {
+		go d.worker(ctx, i, process)
+	}
+}
+
+func (d *partitionedDispatcher) worker(ctx context.Context, idx int, process func(context.Context, model.InboxMessage) error) {
+	for {
+		select {
+		case job := <-d.queues[idx]:
+			key := messageKey(job.msg)
+			if !job.failed.has(key) {
+				if err := process(ctx, job.msg); err != nil {
+					job.failed.add(key, err)
+				}
+			}
+			d.depth[idx].Add(-1)
+			job.wg.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch раздаёт пачку воркерам и ждёт её полной обработки, чтобы
+// следующая выборка не захватила сообщения, которые ещё в работе
+func (d *partitionedDispatcher) dispatch(ctx context.Context, msgs []model.InboxMessage) error {
+	var wg sync.WaitGroup
+	failed := &failedKeys{keys: make(map[string]error)}
+
+	for _, msg := range msgs {
+		idx := d.partition(messageKey(msg))
+		wg.Add(1)
+		d.depth[idx].Add(1)
+		select {
+		case d.queues[idx] <- inboxJob{msg: msg, wg: &wg, failed: failed}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if n, err := failed.first(); err != nil {
+		logger.Log.Errorf("inbox partitioned batch: %d keys failed", n)
+		return err
+	}
+
+	return nil
+}
+
+func (d *partitionedDispatcher) partition(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+func (d *partitionedDispatcher) queueDepths() []int64 {
+	res := make([]int64, len(d.depth))
+	for i := range d.depth {
+		res[i] = d.depth[i].Load()
+	}
+	return res
+}
+
+// messageKey — ключ упорядочивания, то есть order_uid. Сообщение без ключа
+// упорядочивается только само с собой.
+func messageKey(msg model.InboxMessage) string {
+	if msg.Key == "" {
+		return msg.ID
+	}
+	return msg.Key
+}
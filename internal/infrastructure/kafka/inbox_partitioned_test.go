@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionedDispatcher_PreservesOrderWithinKey(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	seen := make(map[string][]string)
+
+	d := newPartitionedDispatcher(4, 100)
+	d.start(ctx, func(_ context.Context, msg model.InboxMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[msg.Key] = append(seen[msg.Key], msg.Payload)
+		return nil
+	})
+
+	var msgs []model.InboxMessage
+	for i := 0; i < 20; i++ {
+		for k := 0; k < 5; k++ {
+			msgs = append(msgs, model.InboxMessage{
+				ID:      fmt.Sprintf("orders/0/%d", len(msgs)),
+				Key:     fmt.Sprintf("key-%d", k),
+				Payload: fmt.Sprint(i),
+			})
+		}
+	}
+
+	assert.NoError(t, d.dispatch(ctx, msgs))
+
+	for k := 0; k < 5; k++ {
+		got := seen[fmt.Sprintf("key-%d", k)]
+		assert.Len(t, got, 20)
+		for i, p := range got {
+			assert.Equal(t, fmt.Sprint(i), p)
+		}
+	}
+	assert.Equal(t, []int64{0, 0, 0, 0}, d.queueDepths())
+}
+
+func TestPartitionedDispatcher_SkipsKeyAfterFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var processed []string
+
+	d := newPartitionedDispatcher(2, 10)
+	d.start(ctx, func(_ context.Context, msg model.InboxMessage) error {
+		if msg.Payload == "bad" {
+			return errors.New("store failed")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, msg.Key+"/"+msg.Payload)
+		return nil
+	})
+
+	err := d.dispatch(ctx, []model.InboxMessage{
+		{ID: "orders/0/1", Key: "a", Payload: "bad"},
+		{ID: "orders/0/2", Key: "b", Payload: "1"},
+		{ID: "orders/0/3", Key: "a", Payload: "2"},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{"b/1"}, processed)
+}
@@ -2,37 +2,99 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
 	"github.com/Babushkin05/wb-orders-service/pkg/logger"
 )
 
+const (
+	ModeSequential  = "sequential"
+	ModePartitioned = "partitioned"
+)
+
 type InboxProcessor interface {
 	Start(ctx context.Context)
-	processBatch(ctx context.Context) error
+	processBatch(ctx context.Context) (int, error)
 }
 
 type inboxProcessor struct {
-	repo application.OrdersRepository
+	repo         application.OrdersRepository
+	cache        application.Cacher
+	policy       *application.ValidationPolicy
+	wake         <-chan struct{}
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	partitions   *partitionedDispatcher
 }
 
-func NewInboxProcessor(repo application.OrdersRepository) InboxProcessor {
-	return &inboxProcessor{
-		repo: repo,
+// NewInboxProcessor создаёт processor. wake — канал пробуждений (например,
+// от Postgres LISTEN), может быть nil: тогда остаётся только опрос по таймеру.
+// cache обновляется после каждого изменения заказа, как и в OrdersService.
+func NewInboxProcessor(
+	cfg config.InboxConfig,
+	repo application.OrdersRepository,
+	cache application.Cacher,
+	policy *application.ValidationPolicy,
+	wake <-chan struct{},
+) InboxProcessor {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+
+	p := &inboxProcessor{
+		repo:         repo,
+		cache:        cache,
+		policy:       policy,
+		wake:         wake,
+		pollInterval: cfg.PollInterval,
+		batchSize:    cfg.BatchSize,
+		maxAttempts:  cfg.MaxAttempts,
 	}
+
+	if cfg.Mode == ModePartitioned {
+		if cfg.Workers <= 0 {
+			cfg.Workers = 4
+		}
+		p.partitions = newPartitionedDispatcher(cfg.Workers, cfg.BatchSize)
+	}
+
+	return p
 }
 
 func (p *inboxProcessor) Start(ctx context.Context) {
+	if p.partitions != nil {
+		p.partitions.start(ctx, p.processMessage)
+	}
+
 	go func() {
-		ticker := time.NewTicker(2 * time.Second)
+		// Таймер — страховочный проход на случай потерянных уведомлений
+		ticker := time.NewTicker(p.pollInterval)
+		defer ticker.Stop()
+
+		wake := p.wake
 		for {
 			select {
 			case <-ticker.C:
-				if err := p.processBatch(ctx); err != nil {
-					logger.Log.Errorf("inbox processor error: %v", err)
+				p.drain(ctx)
+			case _, ok := <-wake:
+				if !ok {
+					logger.Log.Warn("inbox wake channel closed, falling back to polling")
+					wake = nil
+					continue
 				}
+				p.drain(ctx)
 			case <-ctx.Done():
 				logger.Log.Info("inbox processor stopped")
 				return
@@ -41,37 +103,124 @@ func (p *inboxProcessor) Start(ctx context.Context) {
 	}()
 }
 
-func (p *inboxProcessor) processBatch(ctx context.Context) error {
-	msgs, err := p.repo.FetchUnprocessedInboxMessages(ctx, 10)
+// drain обрабатывает пачки, пока inbox не опустеет
+func (p *inboxProcessor) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := p.processBatch(ctx)
+		if err != nil {
+			logger.Log.Errorf("inbox processor error: %v", err)
+			return
+		}
+		if n < p.batchSize {
+			return
+		}
+	}
+}
+
+func (p *inboxProcessor) processBatch(ctx context.Context) (int, error) {
+	msgs, err := p.repo.FetchUnprocessedInboxMessages(ctx, p.batchSize)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if p.partitions != nil {
+		return len(msgs), p.partitions.dispatch(ctx, msgs)
 	}
 
 	for _, msg := range msgs {
-		order, err := model.UnmarshalOrder([]byte(msg.Payload))
-		if err != nil {
-			logger.Log.Errorf("failed to unmarshal order: %v", err)
-			if err := p.repo.MarkInboxMessageProcessed(ctx, msg.ID); err != nil {
-				logger.Log.Errorf(
-					"failed to mark inbox message as processed: %v",
-					err,
-				)
-				return err
-			}
+		if err := p.processMessage(ctx, msg); err != nil {
+			return 0, err
 		}
+	}
 
-		if err := p.repo.Store(order); err != nil {
-			logger.Log.Errorf("failed to store order: %v", err)
-		}
+	return len(msgs), nil
+}
 
-		if err := p.repo.MarkInboxMessageProcessed(ctx, msg.ID); err != nil {
-			logger.Log.Errorf(
-				"failed to mark inbox message as processed: %v",
-				err,
-			)
-			return err
-		}
+// processMessage сохраняет заказ из сообщения. Ошибка записи оставляет
+// сообщение необработанным до следующего прохода, но не дольше maxAttempts
+// попыток. Сообщения, которые повтор не исправит, сразу уходят в dead-letter.
+func (p *inboxProcessor) processMessage(ctx context.Context, msg model.InboxMessage) error {
+	order, err := model.UnmarshalOrder([]byte(msg.Payload))
+	if err != nil {
+		logger.Log.Errorf("failed to unmarshal order: %v", err)
+		return p.deadLetter(ctx, msg)
 	}
 
+	if err := p.policy.Apply(order, msg.Topic); err != nil {
+		logger.Log.Errorf("invalid order %s: %v", order.OrderUID, err)
+		return p.deadLetter(ctx, msg)
+	}
+
+	if order.ValidationStatus != model.ValidationValid {
+		logger.Log.Warnf(
+			"order %s accepted as %s: %v",
+			order.OrderUID, order.ValidationStatus, order.ValidationWarnings,
+		)
+	}
+
+	src := model.ChangeSource{Kind: model.ChangeSourceKafka, ID: msg.ID}
+	result, err := p.repo.Store(application.WithChangeSource(ctx, src), order)
+	if isRejectedByStore(err) {
+		logger.Log.Errorf("order %s rejected by storage: %v", order.OrderUID, err)
+		return p.deadLetter(ctx, msg)
+	}
+	if err != nil {
+		return p.retryLater(ctx, msg, fmt.Errorf("failed to store order %s: %w", order.OrderUID, err))
+	}
+	logger.Log.Debugf("order %s %s", order.OrderUID, result)
+
+	// Заказ уже сохранён: сбой кэша не повод обрабатывать сообщение повторно
+	if err := application.RefreshCache(ctx, p.cache, order, result); err != nil {
+		logger.Log.Warnf("failed to refresh cached order %s: %v", order.OrderUID, err)
+	}
+
+	if err := p.repo.MarkInboxMessageProcessed(ctx, msg.ID); err != nil {
+		logger.Log.Errorf(
+			"failed to mark inbox message as processed: %v",
+			err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (p *inboxProcessor) deadLetter(ctx context.Context, msg model.InboxMessage) error {
+	if err := p.repo.MarkInboxMessageDeadLettered(ctx, msg.ID); err != nil {
+		logger.Log.Errorf(
+			"failed to mark inbox message as dead-lettered: %v",
+			err,
+		)
+		return err
+	}
 	return nil
 }
+
+// retryLater считает неудачную попытку и возвращает cause: сообщение
+// останется необработанным. Исчерпав maxAttempts, сообщение уходит в
+// dead-letter, чтобы не держать inbox. Остановка сервиса попыткой не считается.
+func (p *inboxProcessor) retryLater(ctx context.Context, msg model.InboxMessage, cause error) error {
+	if ctx.Err() != nil {
+		return cause
+	}
+
+	attempts, err := p.repo.RecordInboxMessageFailure(ctx, msg.ID, cause.Error())
+	if err != nil {
+		logger.Log.Errorf("failed to record inbox message failure: %v", err)
+		return cause
+	}
+	if attempts < p.maxAttempts {
+		return cause
+	}
+
+	logger.Log.Errorf("inbox message %s failed %d times, last error: %v", msg.ID, attempts, cause)
+	return p.deadLetter(ctx, msg)
+}
+
+// isRejectedByStore — ошибки Store, которые не исправит повтор
+func isRejectedByStore(err error) bool {
+	return errors.Is(err, application.ErrVersionConflict) ||
+		errors.Is(err, application.ErrOrderArchived) ||
+		errors.Is(err, application.ErrOrderExists) ||
+		errors.Is(err, application.ErrOrderRejected)
+}
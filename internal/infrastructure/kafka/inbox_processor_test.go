@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStoreRepository — репозиторий, у которого Store возвращает err
+type failingStoreRepository struct {
+	application.OrdersRepository
+	err error
+}
+
+func (r *failingStoreRepository) Store(context.Context, *model.Order) (application.StoreResult, error) {
+	return "", r.err
+}
+
+func saveOrderMessage(t *testing.T, repo application.OrdersRepository) model.InboxMessage {
+	t.Helper()
+
+	order := contract.NewOrder()
+	payload, err := model.MarshalOrder(order)
+	require.NoError(t, err)
+
+	msg := model.InboxMessage{
+		ID:      "orders/0/1",
+		Key:     order.OrderUID.String(),
+		Topic:   "orders",
+		Payload: string(payload),
+	}
+	require.NoError(t, repo.SaveInboxMessage(context.Background(), msg))
+	return msg
+}
+
+func TestInboxProcessor_StoreErrorKeepsMessagePending(t *testing.T) {
+	ctx := context.Background()
+	repo := &failingStoreRepository{
+		OrdersRepository: memory.NewOrdersRepository(),
+		err:              errors.New("connection reset"),
+	}
+	msg := saveOrderMessage(t, repo)
+
+	p := NewInboxProcessor(config.InboxConfig{}, repo, memory.NewNopCache(), nil, nil)
+	_, err := p.processBatch(ctx)
+	assert.ErrorIs(t, err, repo.err)
+
+	pending, err := repo.FetchUnprocessedInboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, msg.ID, pending[0].ID)
+}
+
+func TestInboxProcessor_DeadLettersAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	repo := &failingStoreRepository{
+		OrdersRepository: memory.NewOrdersRepository(),
+		err:              errors.New("connection reset"),
+	}
+	saveOrderMessage(t, repo)
+
+	p := NewInboxProcessor(config.InboxConfig{MaxAttempts: 3}, repo, memory.NewNopCache(), nil, nil)
+	for i := 0; i < 2; i++ {
+		_, err := p.processBatch(ctx)
+		assert.ErrorIs(t, err, repo.err)
+	}
+
+	_, err := p.processBatch(ctx)
+	require.NoError(t, err)
+
+	backlog, err := repo.InboxBacklog(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, backlog.Pending)
+}
+
+func TestInboxProcessor_RejectedOrderIsDeadLettered(t *testing.T) {
+	ctx := context.Background()
+
+	for _, storeErr := range []error{
+		application.ErrVersionConflict,
+		application.ErrOrderArchived,
+		application.ErrOrderExists,
+		fmt.Errorf("%w: value out of range", application.ErrOrderRejected),
+	} {
+		repo := &failingStoreRepository{
+			OrdersRepository: memory.NewOrdersRepository(),
+			err:              storeErr,
+		}
+		saveOrderMessage(t, repo)
+
+		p := NewInboxProcessor(config.InboxConfig{}, repo, memory.NewNopCache(), nil, nil)
+		n, err := p.processBatch(ctx)
+		require.NoError(t, err, storeErr)
+		assert.Equal(t, 1, n)
+
+		backlog, err := repo.InboxBacklog(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, backlog.Pending, storeErr)
+	}
+}
+
+func TestInboxProcessor_RefreshesCachedOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewOrdersRepository()
+	cache := memory.NewLRUCache(config.LRUConfig{MaxEntries: 10, TTL: time.Minute}, 0)
+	msg := saveOrderMessage(t, repo)
+
+	stale, err := model.UnmarshalOrder([]byte(msg.Payload))
+	require.NoError(t, err)
+	stale.TrackNumber = "WBILMSTALE"
+	require.NoError(t, cache.Cache(ctx, stale))
+
+	p := NewInboxProcessor(config.InboxConfig{}, repo, cache, nil, nil)
+	_, err = p.processBatch(ctx)
+	require.NoError(t, err)
+
+	got, err := cache.GetOrderFromCache(ctx, msg.Key)
+	require.NoError(t, err)
+	assert.NotEqual(t, "WBILMSTALE", got.TrackNumber)
+}
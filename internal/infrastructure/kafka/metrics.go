@@ -0,0 +1,6 @@
+package kafka
+
+import "expvar"
+
+// inboxMetrics публикуется в /debug/vars
+var inboxMetrics = expvar.NewMap("inbox")
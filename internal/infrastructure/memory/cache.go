@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+// nopCache — кэш, который ничего не хранит. В режиме storage: memory заказы
+// и так лежат в памяти, поэтому отдельный кэш (и Redis) не нужен.
+type nopCache struct{}
+
+var _ application.Cacher = nopCache{}
+
+func NewNopCache() application.Cacher {
+	return nopCache{}
+}
+
+func (nopCache) Cache(_ context.Context, _ *model.Order) error {
+	return nil
+}
+
+func (nopCache) CacheIfAbsent(_ context.Context, _ *model.Order) error {
+	return nil
+}
+
+func (nopCache) CacheNotFound(_ context.Context, _ string) error {
+	return nil
+}
+
+func (nopCache) Invalidate(_ context.Context, _ string) error {
+	return nil
+}
+
+func (nopCache) GetOrderFromCache(_ context.Context, _ string) (model.Order, error) {
+	return model.Order{}, application.ErrCacheMiss
+}
+
+func (nopCache) WarmUp(_ context.Context) error {
+	return nil
+}
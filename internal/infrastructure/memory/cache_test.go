@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCacheContract(t *testing.T) {
+	contract.TestCacher(t, func(t *testing.T) application.Cacher {
+		return NewLRUCache(config.LRUConfig{MaxEntries: 100, TTL: time.Minute}, time.Second)
+	})
+}
+
+func TestTieredCacheContract(t *testing.T) {
+	contract.TestCacher(t, func(t *testing.T) application.Cacher {
+		return NewTieredCache(
+			NewLRUCache(config.LRUConfig{MaxEntries: 100, TTL: time.Minute}, time.Second),
+			NewLRUCache(config.LRUConfig{MaxEntries: 100, TTL: time.Minute}, time.Second),
+		)
+	})
+}
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(config.LRUConfig{MaxEntries: 2}, 0)
+
+	a, b, c := contract.NewOrder(), contract.NewOrder(), contract.NewOrder()
+	require.NoError(t, cache.Cache(ctx, a))
+	require.NoError(t, cache.Cache(ctx, b))
+
+	// Чтение делает a недавним, вытесняется b
+	_, err := cache.GetOrderFromCache(ctx, a.OrderUID.String())
+	require.NoError(t, err)
+	require.NoError(t, cache.Cache(ctx, c))
+
+	_, err = cache.GetOrderFromCache(ctx, b.OrderUID.String())
+	assert.ErrorIs(t, err, application.ErrCacheMiss)
+	_, err = cache.GetOrderFromCache(ctx, a.OrderUID.String())
+	assert.NoError(t, err)
+	_, err = cache.GetOrderFromCache(ctx, c.OrderUID.String())
+	assert.NoError(t, err)
+}
+
+func TestLRUCacheExpires(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(config.LRUConfig{MaxEntries: 10, TTL: time.Minute}, 0).(*lruCache)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	order := contract.NewOrder()
+	require.NoError(t, cache.Cache(ctx, order))
+
+	now = now.Add(59 * time.Second)
+	_, err := cache.GetOrderFromCache(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+
+	now = now.Add(time.Second)
+	_, err = cache.GetOrderFromCache(ctx, order.OrderUID.String())
+	assert.ErrorIs(t, err, application.ErrCacheMiss)
+}
+
+// failingCache — недоступный Redis
+type failingCache struct{}
+
+var errUnavailable = errors.New("unavailable")
+
+func (failingCache) Cache(context.Context, *model.Order) error         { return errUnavailable }
+func (failingCache) CacheIfAbsent(context.Context, *model.Order) error { return errUnavailable }
+func (failingCache) CacheNotFound(context.Context, string) error       { return errUnavailable }
+func (failingCache) Invalidate(context.Context, string) error          { return errUnavailable }
+func (failingCache) GetOrderFromCache(context.Context, string) (model.Order, error) {
+	return model.Order{}, errUnavailable
+}
+func (failingCache) WarmUp(context.Context) error { return errUnavailable }
+
+func TestTieredCacheFillsL1FromL2(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewLRUCache(config.LRUConfig{MaxEntries: 10}, 0)
+	l2 := NewLRUCache(config.LRUConfig{MaxEntries: 10}, 0)
+	cache := NewTieredCache(l1, l2)
+
+	order := contract.NewOrder()
+	require.NoError(t, l2.Cache(ctx, order))
+
+	_, err := cache.GetOrderFromCache(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+
+	got, err := l1.GetOrderFromCache(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	contract.AssertPublicOrderEqual(t, order, &got)
+}
+
+func TestTieredCacheServesL1WhenL2Down(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTieredCache(NewLRUCache(config.LRUConfig{MaxEntries: 10}, 0), failingCache{})
+
+	order := contract.NewOrder()
+	assert.ErrorIs(t, cache.Cache(ctx, order), errUnavailable)
+
+	got, err := cache.GetOrderFromCache(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	contract.AssertPublicOrderEqual(t, order, &got)
+}
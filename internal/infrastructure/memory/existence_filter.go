@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/pkg/bloom"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+)
+
+// filterMetrics публикуется в /debug/vars
+var filterMetrics = expvar.NewMap("existence_filter")
+
+// BloomFilter — фильтр существования заказов на фильтре Блума. До первой
+// перестройки пропускает все запросы.
+type BloomFilter interface {
+	application.ExistenceFilter
+	// Rebuild заново строит фильтр по всем заказам репозитория
+	Rebuild(ctx context.Context) error
+	// Start перестраивает фильтр сразу и затем раз в RebuildInterval,
+	// если он задан, и вне очереди по RequestRebuild
+	Start(ctx context.Context)
+	// RequestRebuild просит Start перестроить фильтр, не дожидаясь
+	// RebuildInterval, например когда уведомления о заказах могли потеряться
+	RequestRebuild()
+}
+
+type bloomFilter struct {
+	cfg  config.BloomConfig
+	repo application.OrdersRepository
+
+	current atomic.Pointer[bloom.Filter]
+	// orders — сколько разных order_uid нашла последняя перестройка
+	orders  atomic.Int64
+	rebuild chan struct{}
+
+	// mu защищает pending: заказы, сохранённые во время перестройки,
+	// добавляются в новый фильтр перед подменой
+	mu         sync.Mutex
+	rebuilding bool
+	pending    []string
+}
+
+func NewBloomFilter(cfg config.BloomConfig, repo application.OrdersRepository) BloomFilter {
+	return &bloomFilter{cfg: cfg, repo: repo, rebuild: make(chan struct{}, 1)}
+}
+
+func (f *bloomFilter) Add(orderUID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cur := f.current.Load(); cur != nil {
+		cur.Add(orderUID)
+	}
+	if f.rebuilding {
+		f.pending = append(f.pending, orderUID)
+	}
+}
+
+func (f *bloomFilter) MayExist(orderUID string) bool {
+	cur := f.current.Load()
+	if cur == nil || cur.MayContain(orderUID) {
+		return true
+	}
+	filterMetrics.Add("rejected", 1)
+	return false
+}
+
+func (f *bloomFilter) Rebuild(ctx context.Context) error {
+	f.mu.Lock()
+	f.rebuilding = true
+	f.pending = nil
+	f.mu.Unlock()
+
+	// Bloom.Len считает и повторные Add, поэтому размер берётся из числа
+	// order_uid, найденных прошлой перестройкой: order_uid в обходе уникальны
+	size := max(f.cfg.ExpectedOrders, 2*int(f.orders.Load()))
+	next := bloom.New(size, f.cfg.FalsePositiveRate)
+
+	start := time.Now()
+	orders := 0
+	err := f.repo.ForEachOrderUID(ctx, func(orderUID string) error {
+		next.Add(orderUID)
+		orders++
+		return nil
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rebuilding = false
+	pending := f.pending
+	f.pending = nil
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range pending {
+		next.Add(uid)
+	}
+	f.current.Store(next)
+	f.orders.Store(int64(orders))
+	filterMetrics.Set("orders", expvarInt(orders))
+
+	logger.Log.Infof("Existence filter rebuilt: %d orders in %s", orders, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func (f *bloomFilter) RequestRebuild() {
+	select {
+	case f.rebuild <- struct{}{}:
+	default:
+	}
+}
+
+func (f *bloomFilter) Start(ctx context.Context) {
+	go func() {
+		var tick <-chan time.Time
+		if f.cfg.RebuildInterval > 0 {
+			ticker := time.NewTicker(f.cfg.RebuildInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			if err := f.Rebuild(ctx); err != nil && ctx.Err() == nil {
+				logger.Log.Error("Existence filter rebuild failed: ", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+			case <-f.rebuild:
+			}
+		}
+	}()
+}
+
+func expvarInt(v int) *expvar.Int {
+	i := new(expvar.Int)
+	i.Set(int64(v))
+	return i
+}
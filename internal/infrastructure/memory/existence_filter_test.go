@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilterRebuildsFromRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewOrdersRepository()
+
+	stored := contract.NewOrder()
+	_, err := repo.Store(ctx, stored)
+	require.NoError(t, err)
+
+	// Доля ложных срабатываний мала, чтобы тест не мигал
+	filter := NewBloomFilter(config.BloomConfig{ExpectedOrders: 100, FalsePositiveRate: 1e-9}, repo)
+	unknown := uuid.NewString()
+
+	// До первой перестройки фильтр ничего не отсекает
+	assert.True(t, filter.MayExist(unknown))
+
+	require.NoError(t, filter.Rebuild(ctx))
+	assert.True(t, filter.MayExist(stored.OrderUID.String()))
+	assert.False(t, filter.MayExist(unknown))
+
+	// Новые заказы попадают в фильтр через обёртку репозитория
+	added := contract.NewOrder()
+	_, err = application.NewFilteredRepository(repo, filter).Store(ctx, added)
+	require.NoError(t, err)
+	assert.True(t, filter.MayExist(added.OrderUID.String()))
+
+	require.NoError(t, filter.Rebuild(ctx))
+	assert.True(t, filter.MayExist(added.OrderUID.String()))
+}
+
+func TestBloomFilterKeepsOrdersAddedDuringRebuild(t *testing.T) {
+	ctx := context.Background()
+	repo := NewOrdersRepository()
+	filter := NewBloomFilter(config.BloomConfig{ExpectedOrders: 100, FalsePositiveRate: 1e-9}, nil).(*bloomFilter)
+
+	added := uuid.NewString()
+	filter.repo = &addDuringScan{OrdersRepository: repo, add: func() { filter.Add(added) }}
+
+	require.NoError(t, filter.Rebuild(ctx))
+	assert.True(t, filter.MayExist(added))
+}
+
+// addDuringScan имитирует Store, пришедший посреди обхода order_uid
+type addDuringScan struct {
+	application.OrdersRepository
+	add func()
+}
+
+func (r *addDuringScan) ForEachOrderUID(ctx context.Context, fn func(string) error) error {
+	r.add()
+	return r.OrdersRepository.ForEachOrderUID(ctx, fn)
+}
+
+func TestBloomFilterSizedByDistinctOrders(t *testing.T) {
+	ctx := context.Background()
+	repo := NewOrdersRepository()
+	for range 3 {
+		_, err := repo.Store(ctx, contract.NewOrder())
+		require.NoError(t, err)
+	}
+
+	filter := NewBloomFilter(config.BloomConfig{ExpectedOrders: 1, FalsePositiveRate: 0.01}, repo).(*bloomFilter)
+	for range 5 {
+		require.NoError(t, filter.Rebuild(ctx))
+		// Повторные Add одних и тех же заказов не раздувают фильтр
+		filter.Add(contract.NewOrder().OrderUID.String())
+	}
+	assert.EqualValues(t, 3, filter.orders.Load())
+}
+
+func TestBloomFilterRequestRebuild(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := NewOrdersRepository()
+
+	filter := NewBloomFilter(config.BloomConfig{ExpectedOrders: 100, FalsePositiveRate: 1e-9}, repo)
+	filter.Start(ctx)
+	require.Eventually(t, func() bool { return !filter.MayExist(uuid.NewString()) }, time.Second, time.Millisecond)
+
+	// Заказ, уведомление о котором потерялось, виден после перестройки вне очереди
+	order := contract.NewOrder()
+	_, err := repo.Store(ctx, order)
+	require.NoError(t, err)
+	filter.RequestRebuild()
+	assert.Eventually(t, func() bool { return filter.MayExist(order.OrderUID.String()) }, time.Second, time.Millisecond)
+}
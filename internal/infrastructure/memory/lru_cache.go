@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+// lruMetrics публикуется в /debug/vars
+var lruMetrics = expvar.NewMap("lru_cache")
+
+type lruEntry struct {
+	key       string
+	order     model.Order
+	missing   bool // заказа нет в БД
+	expiresAt time.Time
+}
+
+// lruCache — ограниченный кэш заказов в памяти процесса
+type lruCache struct {
+	mu          sync.Mutex
+	maxEntries  int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	entries     map[string]*list.Element
+	order       *list.List // от недавно прочитанных к давним
+	now         func() time.Time
+}
+
+var _ application.Cacher = &lruCache{}
+
+// NewLRUCache создаёт кэш. negativeTTL — сколько помнить, что заказа нет;
+// 0 — не запоминать.
+func NewLRUCache(cfg config.LRUConfig, negativeTTL time.Duration) application.Cacher {
+	return &lruCache{
+		maxEntries:  cfg.MaxEntries,
+		ttl:         cfg.TTL,
+		negativeTTL: negativeTTL,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		now:         time.Now,
+	}
+}
+
+func (c *lruCache) Cache(ctx context.Context, order *model.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(&lruEntry{key: order.OrderUID.String(), order: cloneOrder(*order)}, c.ttl)
+	return nil
+}
+
+func (c *lruCache) CacheIfAbsent(ctx context.Context, order *model.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.has(order.OrderUID.String()) {
+		c.put(&lruEntry{key: order.OrderUID.String(), order: cloneOrder(*order)}, c.ttl)
+	}
+	return nil
+}
+
+func (c *lruCache) CacheNotFound(ctx context.Context, orderUID string) error {
+	if err := ctx.Err(); err != nil || c.negativeTTL <= 0 {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.has(orderUID) {
+		c.put(&lruEntry{key: orderUID, missing: true}, c.negativeTTL)
+	}
+	return nil
+}
+
+func (c *lruCache) Invalidate(ctx context.Context, orderUID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[orderUID]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+// has сообщает, есть ли неистёкшая запись. Вызывается под mu.
+func (c *lruCache) has(key string) bool {
+	el, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	expiresAt := el.Value.(*lruEntry).expiresAt
+	return expiresAt.IsZero() || c.now().Before(expiresAt)
+}
+
+func (c *lruCache) put(entry *lruEntry, ttl time.Duration) {
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+
+	if el, ok := c.entries[entry.key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+		lruMetrics.Add("evictions", 1)
+	}
+}
+
+func (c *lruCache) GetOrderFromCache(ctx context.Context, orderUID string) (model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Order{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[orderUID]
+	if !ok {
+		lruMetrics.Add("misses", 1)
+		return model.Order{}, application.ErrCacheMiss
+	}
+	entry := el.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		lruMetrics.Add("expired", 1)
+		lruMetrics.Add("misses", 1)
+		return model.Order{}, application.ErrCacheMiss
+	}
+
+	c.order.MoveToFront(el)
+	if entry.missing {
+		lruMetrics.Add("negative_hits", 1)
+		return model.Order{}, application.ErrOrderNotFound
+	}
+	lruMetrics.Add("hits", 1)
+	return cloneOrder(entry.order), nil
+}
+
+// WarmUp ничего не делает: кэш наполняется чтениями и записями
+func (c *lruCache) WarmUp(_ context.Context) error {
+	return nil
+}
+
+func (c *lruCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).key)
+}
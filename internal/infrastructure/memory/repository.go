@@ -0,0 +1,378 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+type inboxRecord struct {
+	msg          model.InboxMessage
+	seq          int
+	createdAt    time.Time
+	processed    bool
+	deadLettered bool
+	processedAt  time.Time
+	attempts     int
+	lastError    string
+}
+
+// memoryRepository — потокобезопасная реализация OrdersRepository в памяти
+// для локального запуска без Postgres и для тестов. Store работает в режиме
+// upsert. Данные не переживают перезапуск.
+type memoryRepository struct {
+	mu      sync.RWMutex
+	orders  map[string]model.Order
+	history map[string][]model.OrderVersion
+	inbox   map[string]*inboxRecord
+	archive []*inboxRecord
+	nextID  int
+	nextSeq int
+}
+
+var _ application.OrdersRepository = &memoryRepository{}
+
+func NewOrdersRepository() application.OrdersRepository {
+	return &memoryRepository{
+		orders:  make(map[string]model.Order),
+		history: make(map[string][]model.OrderVersion),
+		inbox:   make(map[string]*inboxRecord),
+	}
+}
+
+func (r *memoryRepository) Get(ctx context.Context, orderUID string) (model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Order{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, ok := r.orders[orderUID]
+	if !ok {
+		return model.Order{}, application.ErrOrderNotFound
+	}
+
+	return cloneOrder(order), nil
+}
+
+func (r *memoryRepository) Store(ctx context.Context, order *model.Order) (application.StoreResult, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := cloneOrder(*order)
+	if stored.ValidationStatus == "" {
+		stored.ValidationStatus = model.ValidationValid
+	}
+	stored.Delivery.OrderUID = stored.OrderUID
+	stored.Payment.OrderUID = stored.OrderUID
+
+	key := stored.OrderUID.String()
+	existing, ok := r.orders[key]
+	if order.Version == model.AnyVersion && !ok || order.Version > 0 && order.Version != existing.Version {
+		return "", application.ErrVersionConflict
+	}
+	if ok && existing.SameContent(&stored) {
+		order.Version = existing.Version
+		return application.StoreUnchanged, nil
+	}
+	stored.Version = existing.Version + 1
+
+	// id товаров сохраняются по rid, как в Postgres
+	ids := make(map[string]int, len(existing.Items))
+	for _, it := range existing.Items {
+		ids[it.Rid] = it.ID
+	}
+	for i := range stored.Items {
+		stored.Items[i].OrderUID = stored.OrderUID
+		if id, found := ids[stored.Items[i].Rid]; found {
+			stored.Items[i].ID = id
+			continue
+		}
+		r.nextID++
+		stored.Items[i].ID = r.nextID
+	}
+
+	result := application.StoreCreated
+	if ok {
+		result = application.StoreUpdated
+	}
+
+	r.orders[key] = stored
+	order.Version = stored.Version
+	r.history[key] = append(r.history[key], model.OrderVersion{
+		Version:   len(r.history[key]) + 1,
+		Change:    string(result),
+		Source:    application.ChangeSourceFrom(ctx),
+		ChangedAt: time.Now(),
+		Order:     cloneOrder(stored),
+	})
+
+	return result, nil
+}
+
+func (r *memoryRepository) GetAsOf(ctx context.Context, orderUID string, at time.Time) (model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Order{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.history[orderUID]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !versions[i].ChangedAt.After(at) {
+			return cloneOrder(versions[i].Order), nil
+		}
+	}
+
+	return model.Order{}, application.ErrOrderNotFound
+}
+
+func (r *memoryRepository) OrderHistory(ctx context.Context, orderUID string) ([]model.OrderVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.history[orderUID]
+	if len(versions) == 0 {
+		return nil, application.ErrOrderNotFound
+	}
+
+	res := make([]model.OrderVersion, len(versions))
+	for i, v := range versions {
+		v.Order = cloneOrder(v.Order)
+		res[i] = v
+	}
+	return res, nil
+}
+
+func (r *memoryRepository) ListOrders(ctx context.Context, listing model.OrderListing) ([]model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	var orders []model.Order
+	for _, order := range r.orders {
+		if order.DateCreated.Before(listing.Since) {
+			continue
+		}
+		if listing.After != nil && !newerThan(listing.After, order.Cursor()) {
+			continue
+		}
+		orders = append(orders, cloneOrder(order))
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(orders, func(i, j int) bool {
+		return newerThan(orders[i].Cursor(), orders[j].Cursor())
+	})
+	if listing.Limit > 0 && len(orders) > listing.Limit {
+		orders = orders[:listing.Limit]
+	}
+	return orders, nil
+}
+
+func (r *memoryRepository) ForEachOrderUID(ctx context.Context, fn func(orderUID string) error) error {
+	r.mu.RLock()
+	uids := make([]string, 0, len(r.orders))
+	for uid := range r.orders {
+		uids = append(uids, uid)
+	}
+	r.mu.RUnlock()
+
+	for _, uid := range uids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newerThan сравнивает заказы в порядке ListOrders: a идёт раньше b
+func newerThan(a, b *model.OrderCursor) bool {
+	if !a.DateCreated.Equal(b.DateCreated) {
+		return a.DateCreated.After(b.DateCreated)
+	}
+	return a.OrderUID.String() > b.OrderUID.String()
+}
+
+func (r *memoryRepository) SaveInboxMessage(ctx context.Context, msg model.InboxMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.inbox[msg.ID]; ok {
+		return nil
+	}
+	r.nextSeq++
+	r.inbox[msg.ID] = &inboxRecord{
+		msg:       msg,
+		seq:       r.nextSeq,
+		createdAt: time.Now(),
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) FetchUnprocessedInboxMessages(ctx context.Context, limit int) ([]model.InboxMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pending := r.pendingLocked()
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	msgs := make([]model.InboxMessage, 0, len(pending))
+	for _, rec := range pending {
+		msgs = append(msgs, rec.msg)
+	}
+
+	return msgs, nil
+}
+
+func (r *memoryRepository) MarkInboxMessageProcessed(ctx context.Context, messageID string) error {
+	return r.markInbox(ctx, messageID, false)
+}
+
+func (r *memoryRepository) MarkInboxMessageDeadLettered(ctx context.Context, messageID string) error {
+	return r.markInbox(ctx, messageID, true)
+}
+
+func (r *memoryRepository) markInbox(ctx context.Context, messageID string, deadLettered bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rec, ok := r.inbox[messageID]; ok {
+		rec.processed = true
+		rec.deadLettered = rec.deadLettered || deadLettered
+		rec.processedAt = time.Now()
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) RecordInboxMessageFailure(ctx context.Context, messageID, reason string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.inbox[messageID]
+	if !ok {
+		return 0, nil
+	}
+	rec.attempts++
+	rec.lastError = reason
+
+	return rec.attempts, nil
+}
+
+func (r *memoryRepository) InboxBacklog(ctx context.Context) (model.InboxBacklog, error) {
+	if err := ctx.Err(); err != nil {
+		return model.InboxBacklog{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pending := r.pendingLocked()
+	backlog := model.InboxBacklog{Pending: len(pending)}
+	if len(pending) > 0 {
+		backlog.OldestAge = time.Since(pending[0].createdAt)
+	}
+
+	return backlog, nil
+}
+
+func (r *memoryRepository) PurgeInboxMessages(ctx context.Context, opts model.InboxPurge) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	threshold := time.Now().Add(-opts.OlderThan)
+
+	var expired []*inboxRecord
+	for _, rec := range r.inbox {
+		if rec.processed && rec.deadLettered == opts.DeadLettered && rec.processedAt.Before(threshold) {
+			expired = append(expired, rec)
+		}
+	}
+	sortBySeq(expired)
+	if len(expired) > opts.Limit {
+		expired = expired[:opts.Limit]
+	}
+
+	for _, rec := range expired {
+		delete(r.inbox, rec.msg.ID)
+		if opts.Archive {
+			r.archive = append(r.archive, rec)
+		}
+	}
+
+	return len(expired), nil
+}
+
+// pendingLocked возвращает необработанные сообщения в порядке поступления
+func (r *memoryRepository) pendingLocked() []*inboxRecord {
+	var pending []*inboxRecord
+	for _, rec := range r.inbox {
+		if !rec.processed {
+			pending = append(pending, rec)
+		}
+	}
+	sortBySeq(pending)
+	return pending
+}
+
+// sortBySeq упорядочивает сообщения по времени поступления. Порядковый
+// номер надёжнее created_at: у соседних сообщений время может совпасть.
+func sortBySeq(recs []*inboxRecord) {
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].seq < recs[j].seq
+	})
+}
+
+// cloneOrder копирует заказ вместе со срезами, чтобы вызывающий код
+// не мог изменить сохранённые данные
+func cloneOrder(order model.Order) model.Order {
+	if order.Items != nil {
+		order.Items = append([]model.Item(nil), order.Items...)
+	}
+	if order.ValidationWarnings != nil {
+		order.ValidationWarnings = append(model.FieldErrors(nil), order.ValidationWarnings...)
+	}
+	return order
+}
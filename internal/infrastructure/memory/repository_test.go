@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrdersRepositoryContract(t *testing.T) {
+	contract.TestOrdersRepository(t, func(t *testing.T) application.OrdersRepository {
+		return NewOrdersRepository()
+	})
+}
+
+func TestPurgeInboxMessages_BatchesAndArchivesEveryCopy(t *testing.T) {
+	ctx := context.Background()
+	repo := NewOrdersRepository().(*memoryRepository)
+
+	process := func(msg model.InboxMessage) {
+		require.NoError(t, repo.SaveInboxMessage(ctx, msg))
+		require.NoError(t, repo.MarkInboxMessageProcessed(ctx, msg.ID))
+	}
+	for i := 0; i < 3; i++ {
+		process(model.InboxMessage{ID: fmt.Sprintf("orders/0/%d", i), Topic: "orders"})
+	}
+
+	opts := model.InboxPurge{Limit: 2, Archive: true}
+	n, err := repo.PurgeInboxMessages(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = repo.PurgeInboxMessages(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// сообщение пришло повторно после очистки: в архиве обе копии
+	process(model.InboxMessage{ID: "orders/0/0", Topic: "orders"})
+	n, err = repo.PurgeInboxMessages(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Len(t, repo.archive, 4)
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+)
+
+// Веса полей как в документе order_search Postgres
+const (
+	itemWeight = 1.0
+	cityWeight = 0.4
+)
+
+// SearchOrders — упрощённый поиск: каждое слово запроса должно входить
+// подстрокой в название или бренд товара либо в город доставки. Операторы
+// web-поиска (кавычки, "-", or) не поддерживаются.
+func (r *memoryRepository) SearchOrders(ctx context.Context, search model.OrderSearch) (model.OrderSearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return model.OrderSearchResult{}, err
+	}
+
+	terms := strings.Fields(strings.ToLower(search.Query))
+	if len(terms) == 0 {
+		return model.OrderSearchResult{}, nil
+	}
+
+	r.mu.RLock()
+	var hits []model.OrderSearchHit
+	for _, order := range r.orders {
+		rank, ok := searchRank(&order, terms)
+		if !ok {
+			continue
+		}
+		hits = append(hits, model.OrderSearchHit{
+			OrderUID:     order.OrderUID,
+			TrackNumber:  order.TrackNumber,
+			CustomerID:   order.CustomerID,
+			DateCreated:  order.DateCreated,
+			DeliveryName: order.Delivery.Name,
+			DeliveryCity: order.Delivery.City,
+			Rank:         rank,
+		})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.DateCreated.Equal(b.DateCreated) {
+			return a.DateCreated.After(b.DateCreated)
+		}
+		return a.OrderUID.String() < b.OrderUID.String()
+	})
+
+	result := model.OrderSearchResult{Total: len(hits)}
+	if search.Offset < len(hits) {
+		hits = hits[search.Offset:]
+		if search.Limit > 0 && search.Limit < len(hits) {
+			hits = hits[:search.Limit]
+		}
+		result.Hits = hits
+	}
+	return result, nil
+}
+
+// searchRank считает вхождения слов с весами полей. ok — все слова найдены.
+func searchRank(order *model.Order, terms []string) (rank float64, ok bool) {
+	city := strings.ToLower(order.Delivery.City)
+	for _, term := range terms {
+		found := false
+		for _, it := range order.Items {
+			for _, field := range []string{it.Name, it.Brand} {
+				if strings.Contains(strings.ToLower(field), term) {
+					rank += itemWeight
+					found = true
+				}
+			}
+		}
+		if strings.Contains(city, term) {
+			rank += cityWeight
+			found = true
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return rank, true
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+)
+
+// tieredCache — двухуровневый кэш: l1 в памяти процесса перед общим l2
+// (Redis). Промах в обоих уровнях сервис дочитывает из БД.
+type tieredCache struct {
+	l1 application.Cacher
+	l2 application.Cacher
+}
+
+var _ application.Cacher = &tieredCache{}
+
+func NewTieredCache(l1, l2 application.Cacher) application.Cacher {
+	return &tieredCache{l1: l1, l2: l2}
+}
+
+// Cache пишет в оба уровня. Заказ попадает в l1, даже если l2 недоступен.
+func (c *tieredCache) Cache(ctx context.Context, order *model.Order) error {
+	if err := c.l1.Cache(ctx, order); err != nil {
+		return fmt.Errorf("l1 cache: %w", err)
+	}
+	if err := c.l2.Cache(ctx, order); err != nil {
+		return fmt.Errorf("l2 cache: %w", err)
+	}
+	return nil
+}
+
+// CacheIfAbsent заполняет только l2: в l1 заказ попадёт при чтении из l2,
+// поэтому l1 не разойдётся с l2, если там уже лежит более новая версия.
+// Если l2 недоступен, заказ остаётся хотя бы в l1.
+func (c *tieredCache) CacheIfAbsent(ctx context.Context, order *model.Order) error {
+	if err := c.l2.CacheIfAbsent(ctx, order); err != nil {
+		if err := c.l1.CacheIfAbsent(ctx, order); err != nil {
+			logger.Log.Warnf("tiered cache: failed to fill l1 for order %s: %v", order.OrderUID, err)
+		}
+		return fmt.Errorf("l2 cache: %w", err)
+	}
+	return nil
+}
+
+func (c *tieredCache) CacheNotFound(ctx context.Context, orderUID string) error {
+	if err := c.l1.CacheNotFound(ctx, orderUID); err != nil {
+		return fmt.Errorf("l1 cache: %w", err)
+	}
+	if err := c.l2.CacheNotFound(ctx, orderUID); err != nil {
+		return fmt.Errorf("l2 cache: %w", err)
+	}
+	return nil
+}
+
+// Invalidate удаляет запись из обоих уровней, даже если l2 недоступен
+func (c *tieredCache) Invalidate(ctx context.Context, orderUID string) error {
+	if err := c.l1.Invalidate(ctx, orderUID); err != nil {
+		return fmt.Errorf("l1 cache: %w", err)
+	}
+	if err := c.l2.Invalidate(ctx, orderUID); err != nil {
+		return fmt.Errorf("l2 cache: %w", err)
+	}
+	return nil
+}
+
+// GetOrderFromCache читает l1, при промахе — l2 и кладёт найденное в l1.
+// Запись об отсутствии заказа тоже переносится в l1.
+func (c *tieredCache) GetOrderFromCache(ctx context.Context, orderUID string) (model.Order, error) {
+	order, err := c.l1.GetOrderFromCache(ctx, orderUID)
+	if err == nil || errors.Is(err, application.ErrOrderNotFound) {
+		return order, err
+	}
+
+	order, err = c.l2.GetOrderFromCache(ctx, orderUID)
+	if errors.Is(err, application.ErrOrderNotFound) {
+		if err := c.l1.CacheNotFound(ctx, orderUID); err != nil {
+			logger.Log.Warnf("tiered cache: failed to fill l1 for missing order %s: %v", orderUID, err)
+		}
+		return model.Order{}, err
+	}
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	if err := c.l1.CacheIfAbsent(ctx, &order); err != nil {
+		logger.Log.Warnf("tiered cache: failed to fill l1 for order %s: %v", orderUID, err)
+	}
+	return order, nil
+}
+
+// WarmUp прогревает общий уровень, l1 наполняется чтениями
+func (c *tieredCache) WarmUp(ctx context.Context) error {
+	return c.l2.WarmUp(ctx)
+}
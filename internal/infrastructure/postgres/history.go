@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// insertHistory сохраняет снимок заказа в order_history в транзакции Store.
+// Строка order_keys к этому моменту вставлена или заблокирована этой
+// транзакцией, поэтому номер версии не гоняется с параллельными записями.
+func insertHistory(ctx context.Context, tx *sqlx.Tx, order *model.Order, result application.StoreResult) error {
+	snapshot, err := model.MarshalOrder(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order snapshot: %w", err)
+	}
+
+	src := application.ChangeSourceFrom(ctx)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO order_history (order_uid, version, change, source, source_id, snapshot)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, NULLIF($4, ''), $5
+		FROM order_history
+		WHERE order_uid = $1`,
+		order.OrderUID, string(result), src.Kind, src.ID, snapshot,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert order history: %w", err)
+	}
+	return nil
+}
+
+type historyRow struct {
+	Version   int            `db:"version"`
+	Change    string         `db:"change"`
+	Source    string         `db:"source"`
+	SourceID  sql.NullString `db:"source_id"`
+	ChangedAt time.Time      `db:"changed_at"`
+	Snapshot  []byte         `db:"snapshot"`
+}
+
+func (h historyRow) toVersion() (model.OrderVersion, error) {
+	order, err := model.UnmarshalOrder(h.Snapshot)
+	if err != nil {
+		return model.OrderVersion{}, fmt.Errorf("failed to decode order snapshot: %w", err)
+	}
+
+	return model.OrderVersion{
+		Version:   h.Version,
+		Change:    h.Change,
+		Source:    model.ChangeSource{Kind: h.Source, ID: h.SourceID.String},
+		ChangedAt: h.ChangedAt,
+		Order:     *order,
+	}, nil
+}
+
+func (r *postgresRepository) GetAsOf(ctx context.Context, orderUID string, at time.Time) (model.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
+
+	var row historyRow
+	err := r.read(ctx, orderUID, func(db *sqlx.DB) error {
+		err := db.GetContext(ctx, &row, `
+			SELECT version, change, source, source_id, changed_at, snapshot
+			FROM order_history
+			WHERE order_uid = $1 AND changed_at <= $2
+			ORDER BY version DESC
+			LIMIT 1`,
+			orderUID, at,
+		)
+		if err == sql.ErrNoRows {
+			return application.ErrOrderNotFound
+		}
+		return err
+	})
+	if err != nil {
+		if err == application.ErrOrderNotFound {
+			return model.Order{}, err
+		}
+		return model.Order{}, fmt.Errorf("failed to get order version: %w", err)
+	}
+
+	version, err := row.toVersion()
+	if err != nil {
+		return model.Order{}, err
+	}
+	return version.Order, nil
+}
+
+func (r *postgresRepository) OrderHistory(ctx context.Context, orderUID string) ([]model.OrderVersion, error) {
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
+
+	var rows []historyRow
+	err := r.read(ctx, orderUID, func(db *sqlx.DB) error {
+		return db.SelectContext(ctx, &rows, `
+			SELECT version, change, source, source_id, changed_at, snapshot
+			FROM order_history
+			WHERE order_uid = $1
+			ORDER BY version`,
+			orderUID,
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, application.ErrOrderNotFound
+	}
+
+	versions := make([]model.OrderVersion, 0, len(rows))
+	for _, row := range rows {
+		version, err := row.toVersion()
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
@@ -2,27 +2,37 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
 )
 
-func (r *postgresRepository) SaveInboxMessage(ctx context.Context, messageID, topic, payload string) error {
+func (r *postgresRepository) SaveInboxMessage(ctx context.Context, msg model.InboxMessage) error {
+	// NOTIFY уходит только для реально вставленной строки и доставляется
+	// слушателям после коммита
 	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO inbox (message_id, topic, payload, created_at, processed)
-		VALUES ($1, $2, $3, $4, false)
-		ON CONFLICT (message_id) DO NOTHING
-	`, messageID, topic, payload, time.Now())
+		WITH ins AS (
+			INSERT INTO inbox (message_id, message_key, topic, payload, created_at, processed)
+			VALUES ($1, $2, $3, $4, $5, false)
+			ON CONFLICT (message_id) DO NOTHING
+			RETURNING message_id
+		)
+		SELECT pg_notify($6, message_id) FROM ins
+	`, msg.ID, msg.Key, msg.Topic, msg.Payload, time.Now(), InboxChannel)
 
 	return err
 }
 
+// FetchUnprocessedInboxMessages отдаёт сообщения в порядке поступления (seq):
+// created_at берётся из часов сервиса и может совпадать
 func (r *postgresRepository) FetchUnprocessedInboxMessages(ctx context.Context, limit int) ([]model.InboxMessage, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT message_id, topic, payload
+		SELECT message_id, message_key, topic, payload
 		FROM inbox
 		WHERE processed = false
-		ORDER BY created_at
+		ORDER BY seq
 		LIMIT $1
 	`, limit)
 	if err != nil {
@@ -33,7 +43,7 @@ func (r *postgresRepository) FetchUnprocessedInboxMessages(ctx context.Context,
 	var msgs []model.InboxMessage
 	for rows.Next() {
 		var m model.InboxMessage
-		if err := rows.Scan(&m.ID, &m.Topic, &m.Payload); err != nil {
+		if err := rows.Scan(&m.ID, &m.Key, &m.Topic, &m.Payload); err != nil {
 			return nil, err
 		}
 		msgs = append(msgs, m)
@@ -44,7 +54,100 @@ func (r *postgresRepository) FetchUnprocessedInboxMessages(ctx context.Context,
 
 func (r *postgresRepository) MarkInboxMessageProcessed(ctx context.Context, messageID string) error {
 	_, err := r.db.ExecContext(ctx, `
-		UPDATE inbox SET processed = true WHERE message_id = $1
+		UPDATE inbox SET processed = true, processed_at = LOCALTIMESTAMP WHERE message_id = $1
 	`, messageID)
 	return err
 }
+
+func (r *postgresRepository) MarkInboxMessageDeadLettered(ctx context.Context, messageID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE inbox
+		SET processed = true, dead_lettered = true, processed_at = LOCALTIMESTAMP
+		WHERE message_id = $1
+	`, messageID)
+	return err
+}
+
+func (r *postgresRepository) RecordInboxMessageFailure(ctx context.Context, messageID, reason string) (int, error) {
+	var attempts int
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE inbox SET attempts = attempts + 1, last_error = $2
+		WHERE message_id = $1
+		RETURNING attempts
+	`, messageID, reason).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return attempts, err
+}
+
+func (r *postgresRepository) InboxBacklog(ctx context.Context) (model.InboxBacklog, error) {
+	var (
+		pending int
+		age     float64
+	)
+	// created_at хранится без таймзоны, поэтому сравниваем с LOCALTIMESTAMP
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(EXTRACT(EPOCH FROM LOCALTIMESTAMP - MIN(created_at)), 0)
+		FROM inbox
+		WHERE processed = false
+	`).Scan(&pending, &age)
+	if err != nil {
+		return model.InboxBacklog{}, err
+	}
+
+	return model.InboxBacklog{
+		Pending:   pending,
+		OldestAge: time.Duration(age * float64(time.Second)),
+	}, nil
+}
+
+func (r *postgresRepository) PurgeInboxMessages(ctx context.Context, opts model.InboxPurge) (int, error) {
+	// Пачка выбирается с SKIP LOCKED, чтобы не ждать строк, которые сейчас
+	// обновляет processor, и держать блокировки недолго
+	query := `
+		WITH batch AS (
+			SELECT message_id FROM inbox
+			WHERE processed AND dead_lettered = $1
+				AND COALESCE(processed_at, created_at) < LOCALTIMESTAMP - make_interval(secs => $2)
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), del AS (
+			DELETE FROM inbox i USING batch b
+			WHERE i.message_id = b.message_id
+			RETURNING i.*
+		)
+		SELECT COUNT(*) FROM del
+	`
+	if opts.Archive {
+		query = `
+			WITH batch AS (
+				SELECT message_id FROM inbox
+				WHERE processed AND dead_lettered = $1
+					AND COALESCE(processed_at, created_at) < LOCALTIMESTAMP - make_interval(secs => $2)
+				ORDER BY created_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			), del AS (
+				DELETE FROM inbox i USING batch b
+				WHERE i.message_id = b.message_id
+				RETURNING i.*
+			), ins AS (
+				INSERT INTO inbox_archive (
+					message_id, message_key, topic, payload, dead_lettered, created_at, processed_at,
+					attempts, last_error
+				)
+				SELECT message_id, message_key, topic, payload, dead_lettered, created_at, processed_at,
+					attempts, last_error
+				FROM del
+				RETURNING 1
+			)
+			SELECT COUNT(*) FROM del
+		`
+	}
+
+	var n int
+	err := r.db.QueryRowContext(ctx, query, opts.DeadLettered, opts.OlderThan.Seconds(), opts.Limit).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationsLockID — ключ pg_advisory_lock, чтобы несколько экземпляров
+// сервиса не применяли миграции одновременно
+const migrationsLockID = 7_270_431
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus — версия схемы в БД и версия, которую ждёт код
+type MigrationStatus struct {
+	Current int
+	Dirty   bool
+	Latest  int
+	Pending []string
+}
+
+// Migrator применяет встроенные миграции. Версия хранится в
+// schema_migrations в формате golang-migrate, поэтому базы, накатанные
+// CLI migrate, подхватываются как есть. Каждая миграция выполняется в
+// своей транзакции.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []migration
+}
+
+func NewMigrator(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, file := range files {
+		m := migrationFileRe.FindStringSubmatch(path.Base(file))
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", file)
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: m[2]}
+			byVersion[version] = mig
+		}
+		if m[3] == "up" {
+			mig.up = string(body)
+		} else {
+			mig.down = string(body)
+		}
+	}
+
+	migrator := &Migrator{db: db}
+	for _, mig := range byVersion {
+		if mig.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.version, mig.name)
+		}
+		migrator.migrations = append(migrator.migrations, *mig)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].version < migrator.migrations[j].version
+	})
+
+	return migrator, nil
+}
+
+// Latest — версия последней встроенной миграции
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].version
+}
+
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	current, dirty, err := m.version(ctx, m.db)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	status := MigrationStatus{Current: current, Dirty: dirty, Latest: m.Latest()}
+	for _, mig := range m.migrations {
+		if mig.version > current {
+			status.Pending = append(status.Pending, fmt.Sprintf("%03d_%s", mig.version, mig.name))
+		}
+	}
+	return status, nil
+}
+
+// Check возвращает ошибку, если схема не совпадает с ожидаемой кодом
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("schema version %d is dirty, fix it manually", status.Current)
+	}
+	if status.Current != status.Latest {
+		return fmt.Errorf("schema version %d, expected %d", status.Current, status.Latest)
+	}
+	return nil
+}
+
+// Up применяет все непримененные миграции и возвращает их число
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty, fix it manually", current)
+		}
+
+		for _, mig := range m.migrations {
+			if mig.version <= current {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig.up, mig.version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.version, mig.name, err)
+			}
+			logger.Log.Infof("applied migration %03d_%s", mig.version, mig.name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает steps последних миграций
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty, fix it manually", current)
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mig := m.migrations[i]
+			if mig.version > current {
+				continue
+			}
+			if mig.down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", mig.version, mig.name)
+			}
+
+			prev := 0
+			if i > 0 {
+				prev = m.migrations[i-1].version
+			}
+			if err := m.apply(ctx, conn, mig.down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.version, mig.name, err)
+			}
+			logger.Log.Infof("reverted migration %03d_%s", mig.version, mig.name)
+			current = prev
+			steps--
+		}
+		return nil
+	})
+}
+
+// apply выполняет миграцию и записывает новую версию в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, query string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Пул настроен со statement_timeout для запросов сервиса, а миграция
+	// (перестройка индекса, перенос данных) может идти дольше. SET LOCAL
+	// действует до конца транзакции и не остаётся на соединении пула.
+	if _, err = tx.ExecContext(ctx, `SET LOCAL statement_timeout = 0`); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version > 0 {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// locked выполняет fn на отдельном соединении под advisory lock
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// version читает текущую версию схемы; пустая БД — версия 0
+func (m *Migrator) version(ctx context.Context, q queryRower) (int, bool, error) {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+
+	var (
+		version int
+		dirty   bool
+	)
+	err = q.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
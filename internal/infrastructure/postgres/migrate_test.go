@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Babushkin05/wb-orders-service/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMigrator_Embedded(t *testing.T) {
+	m, err := NewMigrator(nil, migrations.FS)
+	require.NoError(t, err)
+
+	// Версии идут подряд, у каждой миграции есть откат
+	for i, mig := range m.migrations {
+		assert.Equal(t, i+1, mig.version, mig.name)
+		assert.NotEmpty(t, mig.down, mig.name)
+	}
+	assert.Equal(t, len(m.migrations), m.Latest())
+}
+
+func TestNewMigrator_Sorted(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_b.up.sql":   {Data: []byte("SELECT 10")},
+		"010_b.down.sql": {Data: []byte("SELECT -10")},
+		"002_a.up.sql":   {Data: []byte("SELECT 2")},
+	}
+
+	m, err := NewMigrator(nil, fsys)
+	require.NoError(t, err)
+	require.Len(t, m.migrations, 2)
+	assert.Equal(t, 2, m.migrations[0].version)
+	assert.Equal(t, "SELECT -10", m.migrations[1].down)
+	assert.Equal(t, 10, m.Latest())
+}
+
+func TestNewMigrator_Invalid(t *testing.T) {
+	_, err := NewMigrator(nil, fstest.MapFS{"init.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err)
+
+	_, err = NewMigrator(nil, fstest.MapFS{"001_a.down.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err)
+}
+
+func TestMigrator_UpToDate(t *testing.T) {
+	db := testDB(t)
+
+	m, err := NewMigrator(db, migrations.FS)
+	require.NoError(t, err)
+
+	// Тестовая БД накатана целиком, повторный up ничего не делает
+	n, err := m.Up(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.NoError(t, m.Check(context.Background()))
+}
+
+func TestMigrator_ApplyIgnoresStatementTimeout(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	m, err := NewMigrator(db, migrations.FS)
+	require.NoError(t, err)
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	defer conn.ExecContext(ctx, `RESET statement_timeout`)
+
+	// Как у пула сервиса с database.statement_timeout
+	_, err = conn.ExecContext(ctx, `SET statement_timeout = 100`)
+	require.NoError(t, err)
+
+	// Версия не меняется: применяется только долгий запрос
+	version, _, err := m.version(ctx, conn)
+	require.NoError(t, err)
+	assert.NoError(t, m.apply(ctx, conn, `SELECT pg_sleep(0.3)`, version))
+}
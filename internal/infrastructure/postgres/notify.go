@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+	"github.com/lib/pq"
+)
+
+// InboxChannel — канал NOTIFY, в который пишет SaveInboxMessage
+const InboxChannel = "inbox_new"
+
+// OrderKeysChannel — канал NOTIFY, в который триггер на order_keys пишет
+// order_uid каждого нового заказа
+const OrderKeysChannel = "order_keys_new"
+
+// InboxListener слушает InboxChannel и будит inbox processor
+type InboxListener struct {
+	listener *pq.Listener
+	wake     chan struct{}
+}
+
+func NewInboxListener(cfg config.DBConfig) (*InboxListener, error) {
+	listener, err := listen(cfg, InboxChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &InboxListener{
+		listener: listener,
+		wake:     make(chan struct{}, 1),
+	}
+	go l.run()
+
+	return l, nil
+}
+
+// Wake возвращает канал пробуждений. Несколько уведомлений подряд
+// схлопываются в одно — processor всё равно выбирает пачку целиком.
+func (l *InboxListener) Wake() <-chan struct{} {
+	return l.wake
+}
+
+func (l *InboxListener) Close() error {
+	return l.listener.Close()
+}
+
+func (l *InboxListener) run() {
+	// nil приходит после переподключения: уведомления могли потеряться,
+	// поэтому тоже будим processor
+	for range l.listener.Notify {
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+	}
+	close(l.wake)
+}
+
+// OrderKeysListener слушает OrderKeysChannel, чтобы заказы, сохранённые
+// другими экземплярами сервиса, сразу попадали в фильтр существования
+type OrderKeysListener struct {
+	listener *pq.Listener
+}
+
+// NewOrderKeysListener вызывает add для каждого нового заказа. После
+// переподключения уведомления могли потеряться — тогда вызывается lost.
+func NewOrderKeysListener(cfg config.DBConfig, add func(orderUID string), lost func()) (*OrderKeysListener, error) {
+	listener, err := listen(cfg, OrderKeysChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for n := range listener.Notify {
+			if n == nil {
+				lost()
+				continue
+			}
+			add(n.Extra)
+		}
+	}()
+
+	return &OrderKeysListener{listener: listener}, nil
+}
+
+func (l *OrderKeysListener) Close() error {
+	return l.listener.Close()
+}
+
+func listen(cfg config.DBConfig, channel string) (*pq.Listener, error) {
+	listener := pq.NewListener(DSN(cfg), time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Log.Errorf("%s listener event %d: %v", channel, ev, err)
+		}
+	})
+
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen %s: %w", channel, err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Таблицы, партиционированные по месяцу date_created. Архивная партиция
+// переносится из table в table+"_archive" под тем же именем.
+var partitionedTables = []string{"orders", "items"}
+
+type PartitionMaintainer interface {
+	Start(ctx context.Context)
+}
+
+// partitionMaintainer заранее создаёт месячные партиции orders/items
+// и переносит старые в orders_archive/items_archive. Архивные партиции
+// остаются доступны на чтение через getOrder.
+type partitionMaintainer struct {
+	cfg config.PartitionConfig
+	db  *sqlx.DB
+}
+
+func NewPartitionMaintainer(cfg config.PartitionConfig, db *sqlx.DB) PartitionMaintainer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = 5 * time.Second
+	}
+
+	return &partitionMaintainer{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (m *partitionMaintainer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			m.run(ctx)
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("partition maintainer stopped")
+				return
+			}
+		}
+	}()
+}
+
+func (m *partitionMaintainer) run(ctx context.Context) {
+	current := monthStart(time.Now())
+
+	for i := 0; i <= m.cfg.PremakeMonths; i++ {
+		month := current.AddDate(0, i, 0)
+		if err := m.createPartitions(ctx, month); err != nil {
+			logger.Log.Errorf("partition maintainer: create %s: %v", month.Format("2006-01"), err)
+		}
+	}
+
+	if m.cfg.ArchiveAfter > 0 {
+		m.archiveOld(ctx, current.AddDate(0, -m.cfg.ArchiveAfter, 0))
+	}
+
+	if err := m.archiveDefaultRows(ctx); err != nil {
+		logger.Log.Errorf("partition maintainer: archive default rows: %v", err)
+	}
+}
+
+// archiveOld переносит в архив рабочие партиции месяцев до cutoff
+func (m *partitionMaintainer) archiveOld(ctx context.Context, cutoff time.Time) {
+	months, err := m.partitionMonths(ctx, "orders")
+	if err != nil {
+		logger.Log.Errorf("partition maintainer: list partitions: %v", err)
+		return
+	}
+	for _, month := range months {
+		if !month.Before(cutoff) || ctx.Err() != nil {
+			continue
+		}
+		if err := m.archivePartitions(ctx, month); err != nil {
+			logger.Log.Errorf("partition maintainer: archive %s: %v", month.Format("2006-01"), err)
+			continue
+		}
+		logger.Log.Infof("partition maintainer: archived %s", month.Format("2006-01"))
+	}
+}
+
+// createPartitions создаёт партиции месяца, если их ещё нет. Строки месяца,
+// попавшие в партицию по умолчанию, мешают создать партицию, поэтому она
+// собирается отдельной таблицей, строки переносятся в неё и она
+// присоединяется.
+func (m *partitionMaintainer) createPartitions(ctx context.Context, month time.Time) error {
+	from, to := partitionBounds(month)
+	return m.inTx(ctx, func(tx *sqlx.Tx) error {
+		for _, table := range partitionedTables {
+			name := partitionName(table, month)
+
+			var exists, hasDefaultRows bool
+			if err := tx.GetContext(ctx, &exists, `SELECT to_regclass($1) IS NOT NULL`, name); err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			query := fmt.Sprintf(
+				`SELECT EXISTS (SELECT 1 FROM %s_default WHERE date_created >= '%s' AND date_created < '%s')`,
+				table, from, to,
+			)
+			if err := tx.GetContext(ctx, &hasDefaultRows, query); err != nil {
+				return err
+			}
+
+			if !hasDefaultRows {
+				query = fmt.Sprintf(
+					`CREATE TABLE %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+					name, table, from, to,
+				)
+				if _, err := tx.ExecContext(ctx, query); err != nil {
+					return err
+				}
+				continue
+			}
+
+			query = fmt.Sprintf(`CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)`, name, table)
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return err
+			}
+			if err := moveDefaultRows(ctx, tx, table, name, month); err != nil {
+				return err
+			}
+			// Таблица маленькая, CHECK проверяется сразу
+			if _, err := tx.ExecContext(ctx, addBoundsCheckQuery(name, month, "")); err != nil {
+				return err
+			}
+			if err := attachPartition(ctx, tx, table, name, month); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// archivePartitions переносит партиции месяца в архивные таблицы.
+//
+// DETACH ... CONCURRENTLY недоступен, пока у таблицы есть партиция по
+// умолчанию, поэтому DETACH и ATTACH идут одной транзакцией, чтобы заказ и
+// его товары не разъехались. ACCESS EXCLUSIVE в ней держится недолго:
+// CHECK с границами месяца проверен заранее, без блокировки чтения и записи,
+// и ATTACH не сканирует партицию.
+func (m *partitionMaintainer) archivePartitions(ctx context.Context, month time.Time) error {
+	for _, table := range partitionedTables {
+		if err := m.validateBounds(ctx, partitionName(table, month), month); err != nil {
+			return err
+		}
+	}
+
+	return m.inTx(ctx, func(tx *sqlx.Tx) error {
+		for _, table := range partitionedTables {
+			name := partitionName(table, month)
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s`, table, name)); err != nil {
+				return err
+			}
+			if err := attachPartition(ctx, tx, table+"_archive", name, month); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// archiveDefaultRows переносит в архивные партиции заказы, сохранённые с
+// датой уже заархивированного месяца: такие строки попадают в партицию по
+// умолчанию
+func (m *partitionMaintainer) archiveDefaultRows(ctx context.Context) error {
+	archived, err := m.partitionMonths(ctx, "orders_archive")
+	if err != nil {
+		return err
+	}
+	var months []time.Time
+	err = m.db.SelectContext(ctx, &months,
+		`SELECT DISTINCT date_trunc('month', date_created, 'UTC') FROM orders_default`)
+	if err != nil {
+		return err
+	}
+
+	for _, month := range months {
+		// date_trunc возвращает timestamptz в часовом поясе сессии, а имена
+		// и границы партиций считаются в UTC
+		month = month.UTC()
+		if !slices.ContainsFunc(archived, month.Equal) || ctx.Err() != nil {
+			continue
+		}
+		err := m.inTx(ctx, func(tx *sqlx.Tx) error {
+			for _, table := range partitionedTables {
+				if err := moveDefaultRows(ctx, tx, table, partitionName(table, month), month); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", month.Format("2006-01"), err)
+		}
+		logger.Log.Infof("partition maintainer: archived default rows of %s", month.Format("2006-01"))
+	}
+	return nil
+}
+
+// validateBounds добавляет партиции CHECK с границами месяца. Добавление
+// NOT VALID не сканирует таблицу, а VALIDATE сканирует её под
+// SHARE UPDATE EXCLUSIVE, не мешая чтению и записи.
+func (m *partitionMaintainer) validateBounds(ctx context.Context, name string, month time.Time) error {
+	err := m.inTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, addBoundsCheckQuery(name, month, "NOT VALID"))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return m.inTx(ctx, func(tx *sqlx.Tx) error {
+		// Проверка большой партиции может идти дольше statement_timeout
+		if _, err := tx.ExecContext(ctx, `SET LOCAL statement_timeout = 0`); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s VALIDATE CONSTRAINT %s`, name, boundsCheckName(name)))
+		return err
+	})
+}
+
+// inTx выполняет fn в транзакции с lock_timeout: пока ALTER TABLE ждёт
+// блокировку, за ним встают все запросы к таблице, поэтому лучше
+// отступить и повторить на следующем проходе
+func (m *partitionMaintainer) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`SET LOCAL lock_timeout = %d`, m.cfg.LockTimeout.Milliseconds())
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// attachPartition присоединяет name к parent. У name уже есть проверенный
+// CHECK с границами месяца, поэтому ATTACH не сканирует таблицу; после
+// присоединения CHECK дублирует ограничение партиции и удаляется.
+func attachPartition(ctx context.Context, tx *sqlx.Tx, parent, name string, month time.Time) error {
+	from, to := partitionBounds(month)
+	query := fmt.Sprintf(
+		`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`,
+		parent, name, from, to,
+	)
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT %s`, name, boundsCheckName(name)))
+	return err
+}
+
+// moveDefaultRows переносит строки месяца из партиции по умолчанию table
+// в таблицу target
+func moveDefaultRows(ctx context.Context, tx *sqlx.Tx, table, target string, month time.Time) error {
+	from, to := partitionBounds(month)
+	query := fmt.Sprintf(`WITH moved AS (
+			DELETE FROM %s_default WHERE date_created >= '%s' AND date_created < '%s' RETURNING *
+		)
+		INSERT INTO %s SELECT * FROM moved`,
+		table, from, to, target,
+	)
+	_, err := tx.ExecContext(ctx, query)
+	return err
+}
+
+// addBoundsCheckQuery (пере)создаёт CHECK с границами месяца; option —
+// "" или "NOT VALID"
+func addBoundsCheckQuery(name string, month time.Time, option string) string {
+	from, to := partitionBounds(month)
+	check := boundsCheckName(name)
+	return fmt.Sprintf(
+		`ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s, ADD CONSTRAINT %s CHECK (date_created >= '%s' AND date_created < '%s') %s`,
+		name, check, check, from, to, option,
+	)
+}
+
+func boundsCheckName(name string) string {
+	return name + "_bounds"
+}
+
+// partitionMonths возвращает месяцы, для которых у parent есть месячная партиция
+func (m *partitionMaintainer) partitionMonths(ctx context.Context, parent string) ([]time.Time, error) {
+	var names []string
+	err := m.db.SelectContext(ctx, &names, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = $1`, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Архивная партиция называется так же, как рабочая
+	table := strings.TrimSuffix(parent, "_archive")
+	months := make([]time.Time, 0, len(names))
+	for _, name := range names {
+		// orders_default и прочие партиции не по месяцам пропускаем
+		if month, ok := parsePartitionName(table, name); ok {
+			months = append(months, month)
+		}
+	}
+	return months, nil
+}
+
+// monthStart — начало месяца t в UTC; границы партиций считаются в UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// partitionName — имя месячной партиции, как в миграции 006: orders_y2025m01
+func partitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_y%04dm%02d", table, month.Year(), int(month.Month()))
+}
+
+func parsePartitionName(table, name string) (time.Time, bool) {
+	var year, month int
+	n, err := fmt.Sscanf(name, table+"_y%04dm%02d", &year, &month)
+	if err != nil || n != 2 || month < 1 || month > 12 || name != partitionName(table, time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)) {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), true
+}
+
+func partitionBounds(month time.Time) (from, to string) {
+	const layout = "2006-01-02 15:04:05-07"
+	return month.Format(layout), month.AddDate(0, 1, 0).Format(layout)
+}
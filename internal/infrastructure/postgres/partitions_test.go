@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionName_RoundTrip(t *testing.T) {
+	month := monthStart(time.Date(2025, time.February, 1, 1, 0, 0, 0, time.FixedZone("MSK", 3*3600)))
+
+	// Граница месяца считается в UTC
+	assert.Equal(t, "orders_y2025m01", partitionName("orders", month))
+
+	parsed, ok := parsePartitionName("orders", "orders_y2025m01")
+	assert.True(t, ok)
+	assert.Equal(t, month, parsed)
+}
+
+func TestParsePartitionName_SkipsForeign(t *testing.T) {
+	for _, name := range []string{"orders_default", "items_y2025m01", "orders_y2025m13", "orders_y2025m1"} {
+		_, ok := parsePartitionName("orders", name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestPartitionBounds(t *testing.T) {
+	from, to := partitionBounds(time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, "2025-12-01 00:00:00+00", from)
+	assert.Equal(t, "2026-01-01 00:00:00+00", to)
+}
+
+func TestAddBoundsCheckQuery(t *testing.T) {
+	month := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t,
+		`ALTER TABLE orders_y2025m12 DROP CONSTRAINT IF EXISTS orders_y2025m12_bounds, `+
+			`ADD CONSTRAINT orders_y2025m12_bounds CHECK (date_created >= '2025-12-01 00:00:00+00' `+
+			`AND date_created < '2026-01-01 00:00:00+00') NOT VALID`,
+		addBoundsCheckQuery("orders_y2025m12", month, "NOT VALID"),
+	)
+}
+
+// testPartitionMaintainer возвращает maintainer и сохраняет заказ с датой
+// в month. Месяцы в тестах далеко в прошлом, чтобы не задеть рабочие
+// партиции; созданные партиции и заказы удаляются после теста.
+func testPartitionMaintainer(t *testing.T, db *sqlx.DB, month time.Time) *partitionMaintainer {
+	t.Cleanup(func() {
+		for _, table := range partitionedTables {
+			db.Exec(`DROP TABLE IF EXISTS ` + partitionName(table, month))
+		}
+		from, to := partitionBounds(month)
+		db.Exec(`DELETE FROM order_keys WHERE date_created >= $1 AND date_created < $2`, from, to)
+	})
+
+	return NewPartitionMaintainer(config.PartitionConfig{}, db).(*partitionMaintainer)
+}
+
+func storeOrderAt(t *testing.T, repo application.OrdersRepository, date time.Time) *model.Order {
+	order := contract.NewOrder()
+	order.DateCreated = date
+	_, err := repo.Store(context.Background(), order)
+	require.NoError(t, err)
+	return order
+}
+
+// partitionOf возвращает таблицу, в которой лежит строка заказа, и её родителя
+func partitionOf(t *testing.T, db *sqlx.DB, table string, order *model.Order) (name, parent string) {
+	var row struct {
+		Name   string `db:"name"`
+		Parent string `db:"parent"`
+	}
+	err := db.Get(&row, `
+		SELECT c.relname AS name, COALESCE(p.relname, '') AS parent
+		FROM `+table+` t
+		JOIN pg_class c ON c.oid = t.tableoid
+		LEFT JOIN pg_inherits i ON i.inhrelid = c.oid
+		LEFT JOIN pg_class p ON p.oid = i.inhparent
+		WHERE t.order_uid = $1
+		LIMIT 1`, order.OrderUID)
+	require.NoError(t, err)
+	return row.Name, row.Parent
+}
+
+func TestPartitionMaintainer_CreateMovesDefaultRows(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	month := time.Date(1991, time.March, 1, 0, 0, 0, 0, time.UTC)
+	m := testPartitionMaintainer(t, db, month)
+	repo := NewOrdersRepository(db, nil, config.DBConfig{StoreMode: StoreModeUpsert})
+
+	// Партиции месяца ещё нет, заказ попадает в партицию по умолчанию
+	order := storeOrderAt(t, repo, month.Add(36*time.Hour))
+	name, _ := partitionOf(t, db, "orders", order)
+	require.Equal(t, "orders_default", name)
+
+	require.NoError(t, m.createPartitions(ctx, month))
+	// Повторный запуск ничего не меняет
+	require.NoError(t, m.createPartitions(ctx, month))
+
+	for _, table := range partitionedTables {
+		name, parent := partitionOf(t, db, table, order)
+		assert.Equal(t, partitionName(table, month), name)
+		assert.Equal(t, table, parent)
+	}
+
+	var checks int
+	require.NoError(t, db.Get(&checks,
+		`SELECT COUNT(*) FROM pg_constraint WHERE conname = $1`, boundsCheckName(partitionName("orders", month))))
+	assert.Zero(t, checks)
+
+	got, err := repo.Get(ctx, order.OrderUID.String())
+	require.NoError(t, err)
+	assert.Len(t, got.Items, len(order.Items))
+}
+
+func TestPartitionMaintainer_ArchivesPartitionsAndDefaultRows(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	month := time.Date(1992, time.May, 1, 0, 0, 0, 0, time.UTC)
+	m := testPartitionMaintainer(t, db, month)
+	repo := NewOrdersRepository(db, nil, config.DBConfig{StoreMode: StoreModeUpsert})
+
+	require.NoError(t, m.createPartitions(ctx, month))
+	archived := storeOrderAt(t, repo, month.Add(time.Hour))
+
+	require.NoError(t, m.archivePartitions(ctx, month))
+	for _, table := range partitionedTables {
+		name, parent := partitionOf(t, db, table, archived)
+		assert.Equal(t, partitionName(table, month), name)
+		assert.Equal(t, table+"_archive", parent)
+	}
+
+	got, err := repo.Get(ctx, archived.OrderUID.String())
+	require.NoError(t, err)
+	assert.Equal(t, archived.OrderUID, got.OrderUID)
+	archived.TrackNumber = "WBILMARCHIVED"
+	_, err = repo.Store(ctx, archived)
+	assert.ErrorIs(t, err, application.ErrOrderArchived)
+
+	// Заказ с датой заархивированного месяца попадает в партицию по умолчанию,
+	// а на следующем проходе переезжает в архив
+	late := storeOrderAt(t, repo, month.Add(48*time.Hour))
+	name, _ := partitionOf(t, db, "orders", late)
+	require.Equal(t, "orders_default", name)
+
+	require.NoError(t, m.archiveDefaultRows(ctx))
+	for _, table := range partitionedTables {
+		name, parent := partitionOf(t, db, table, late)
+		assert.Equal(t, partitionName(table, month), name)
+		assert.Equal(t, table+"_archive", parent)
+	}
+	_, err = repo.Get(ctx, late.OrderUID.String())
+	require.NoError(t, err)
+}
@@ -1,43 +1,114 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
-type DBConfig struct {
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	Name     string `yaml:"name"`
+// DSN собирает строку подключения к Postgres из конфига. Если задан
+// cfg.DSN, он возвращается как есть.
+func DSN(cfg config.DBConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	params := []string{
+		"host=" + dsnValue(cfg.Host),
+		"port=" + strconv.Itoa(cfg.Port),
+		"user=" + dsnValue(cfg.User),
+		"password=" + dsnValue(cfg.Password),
+		"dbname=" + dsnValue(cfg.Name),
+		"sslmode=" + dsnValue(sslMode),
+	}
+	optional := []struct{ key, value string }{
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+		{"application_name", cfg.ApplicationName},
+	}
+	for _, p := range optional {
+		if p.value != "" {
+			params = append(params, p.key+"="+dsnValue(p.value))
+		}
+	}
+	// lib/pq передаёт неизвестные параметры серверу как настройки сессии
+	if cfg.StatementTimeout > 0 {
+		params = append(params, "statement_timeout="+strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+
+	return strings.Join(params, " ")
 }
 
-func NewDB(cfg DBConfig) (*sqlx.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Host,
-		cfg.Port,
-		cfg.User,
-		cfg.Password,
-		cfg.Name,
-	)
+// dsnValue экранирует значение для key=value строки подключения
+func dsnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
 
-	db, err := sqlx.Connect("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+// NewDB подключается к primary. Пока Postgres недоступен (например, ещё
+// стартует рядом в docker compose), подключение повторяется с
+// экспоненциальной задержкой cfg.Connect.
+func NewDB(ctx context.Context, cfg config.DBConfig) (*sqlx.DB, error) {
+	attempts := cfg.Connect.Attempts
+	if attempts <= 0 {
+		attempts = 1
 	}
+	backoff := cfg.Connect.InitialBackoff
 
-	if err = db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	var err error
+	for attempt := 1; ; attempt++ {
+		var db *sqlx.DB
+		db, err = sqlx.ConnectContext(ctx, "postgres", DSN(cfg))
+		if err == nil {
+			configurePool(db, cfg.Pool)
+			return db, nil
+		}
+		if attempt >= attempts || ctx.Err() != nil {
+			break
+		}
+
+		logger.Log.Warnf("failed to connect to database (attempt %d/%d), retrying in %s: %v", attempt, attempts, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		backoff = nextBackoff(backoff, cfg.Connect.MaxBackoff)
 	}
 
-	// Настройка пула соединений
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	return nil, fmt.Errorf("failed to connect to database: %w", err)
+}
+
+func configurePool(db *sqlx.DB, cfg config.PoolConfig) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
 
-	return db, nil
+func nextBackoff(cur, max time.Duration) time.Duration {
+	if cur <= 0 {
+		cur = 100 * time.Millisecond
+	}
+	next := cur * 2
+	if max > 0 && next > max {
+		return max
+	}
+	return next
 }
@@ -3,96 +3,431 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// Режимы Store
+const (
+	// StoreModeInsert — повторное сохранение заказа завершается ошибкой
+	StoreModeInsert = "insert"
+	// StoreModeUpsert — повторное сохранение обновляет заказ или ничего не делает
+	StoreModeUpsert = "upsert"
+)
+
+// uniqueViolation — код ошибки Postgres при нарушении уникальности
+const uniqueViolation = "23505"
+
+// rejectedData оборачивает в application.ErrOrderRejected ошибки Postgres
+// классов 22 (data exception) и 23 (integrity constraint violation): их
+// вызывают сами данные заказа, и повтор записи не поможет
+func rejectedData(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22", "23":
+			return fmt.Errorf("%w: %w", application.ErrOrderRejected, err)
+		}
+	}
+	return err
+}
+
 type postgresRepository struct {
-	db *sqlx.DB
+	db           *sqlx.DB
+	replicas     *ReplicaSet
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	storeMode    string
 }
 
-func NewOrdersRepository(db *sqlx.DB) application.OrdersRepository {
-	return &postgresRepository{db: db}
+// NewOrdersRepository создаёт репозиторий поверх primary. Чтения заказов
+// уходят на replicas, если они заданы; replicas может быть nil.
+func NewOrdersRepository(db *sqlx.DB, replicas *ReplicaSet, cfg config.DBConfig) application.OrdersRepository {
+	return &postgresRepository{
+		db:           db,
+		replicas:     replicas,
+		readTimeout:  cfg.ReadTimeout,
+		writeTimeout: cfg.WriteTimeout,
+		storeMode:    cfg.StoreMode,
+	}
 }
 
-func (r *postgresRepository) Get(orderUID string) (model.Order, error) {
-	ctx := context.Background()
+// withTimeout ограничивает операцию таймаутом из конфига, если он задан
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
 
-	// Начинаем транзакцию
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return model.Order{}, fmt.Errorf("failed to begin transaction: %w", err)
+// read выполняет чтение заказа на реплике, а если реплика ответила
+// ошибкой — помечает её недоступной и повторяет чтение на primary.
+// Пустой orderUID — чтение не одного заказа (поиск), без read-your-writes.
+func (r *postgresRepository) read(ctx context.Context, orderUID string, fn func(db *sqlx.DB) error) error {
+	rep := r.replicas.reader(orderUID)
+	if rep == nil {
+		return fn(r.db)
 	}
-	defer tx.Rollback()
 
-	// Получаем основной заказ
+	err := fn(rep.db)
+	if err == nil || errors.Is(err, application.ErrOrderNotFound) || ctx.Err() != nil {
+		return err
+	}
+	r.replicas.setHealthy(rep, err)
+	return fn(r.db)
+}
+
+// selectOrdersQuery загружает заказы целиком: доставка и платёж
+// присоединяются JOIN-ом, товары собираются в JSON-массив. Колонки
+// перечислены явно, чтобы новые колонки в схеме не ломали сканирование.
+const selectOrdersQuery = `
+	SELECT
+		o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
+		o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.date_created,
+		o.oof_shard, o.validation_status, o.validation_warnings, o.version,
+		d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+		p.transaction, p.request_id, p.currency, p.provider, p.amount,
+		p.payment_dt, p.bank, p.delivery_cost, p.goods_total, p.custom_fee,
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'id', i.id,
+				'chrt_id', i.chrt_id,
+				'track_number', i.track_number,
+				'price', i.price,
+				'rid', i.rid,
+				'name', i.name,
+				'sale', i.sale,
+				'size', i.size,
+				'total_price', i.total_price,
+				'nm_id', i.nm_id,
+				'brand', i.brand,
+				'status', i.status
+			) ORDER BY i.id)
+			FROM items i
+			WHERE i.order_uid = o.order_uid AND i.date_created = o.date_created
+		), '[]'::json) AS items
+	FROM orders o
+	JOIN delivery d ON d.order_uid = o.order_uid
+	JOIN payment p ON p.order_uid = o.order_uid`
+
+// getOrderQuery загружает один заказ. Дата из order_keys отсекает все
+// месячные партиции, кроме одной.
+const getOrderQuery = selectOrdersQuery + `
+	WHERE o.order_uid = $1
+		AND o.date_created = (SELECT date_created FROM order_keys WHERE order_uid = $1)`
+
+// listOrdersQuery — страница заказов от новых к старым не старше $1,
+// начиная после ключа ($2, $3) предыдущей страницы. order_uid сравнивается
+// побайтно, как строки uuid в Go.
+const listOrdersQuery = selectOrdersQuery + `
+	WHERE o.date_created >= $1
+		AND ($2::timestamptz IS NULL OR (o.date_created, o.order_uid COLLATE "C") < ($2, $3))
+	ORDER BY o.date_created DESC, o.order_uid COLLATE "C" DESC
+	LIMIT $4`
+
+// getArchivedOrderQuery — тот же запрос по архивным партициям
+var getArchivedOrderQuery = strings.NewReplacer(
+	"FROM items i", "FROM items_archive i",
+	"FROM orders o", "FROM orders_archive o",
+).Replace(getOrderQuery)
+
+// itemRow — элемент JSON-массива items из getOrderQuery
+type itemRow struct {
+	ID          int         `json:"id"`
+	ChrtID      int         `json:"chrt_id"`
+	TrackNumber string      `json:"track_number"`
+	Price       model.Money `json:"price"`
+	Rid         string      `json:"rid"`
+	Name        string      `json:"name"`
+	Sale        int         `json:"sale"`
+	Size        string      `json:"size"`
+	TotalPrice  model.Money `json:"total_price"`
+	NmID        int         `json:"nm_id"`
+	Brand       string      `json:"brand"`
+	Status      int         `json:"status"`
+}
+
+func (r *postgresRepository) Get(ctx context.Context, orderUID string) (model.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
+
 	var order model.Order
-	query := `SELECT * FROM orders WHERE order_uid = $1`
-	err = tx.GetContext(ctx, &order, query, orderUID)
+	err := r.read(ctx, orderUID, func(db *sqlx.DB) (err error) {
+		order, _, err = getOrder(ctx, db, orderUID)
+		return err
+	})
+	return order, err
+}
+
+// queryRower — *sqlx.DB или *sqlx.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// getOrder ищет заказ в рабочих партициях, затем в архивных.
+// archived сообщает, что заказ найден в архиве.
+func getOrder(ctx context.Context, q queryRower, orderUID string) (order model.Order, archived bool, err error) {
+	order, err = scanOrder(ctx, q, getOrderQuery, orderUID)
+	if err != application.ErrOrderNotFound {
+		return order, false, err
+	}
+
+	order, err = scanOrder(ctx, q, getArchivedOrderQuery, orderUID)
+	return order, err == nil, err
+}
+
+func scanOrder(ctx context.Context, q queryRower, query, orderUID string) (model.Order, error) {
+	order, err := scanOrderRow(q.QueryRowContext(ctx, query, orderUID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return model.Order{}, fmt.Errorf("order not found")
+			return model.Order{}, application.ErrOrderNotFound
 		}
 		return model.Order{}, fmt.Errorf("failed to get order: %w", err)
 	}
+	return order, nil
+}
+
+// rowScanner — *sql.Row или *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	// Получаем доставку
-	var delivery model.Delivery
-	query = `SELECT * FROM delivery WHERE order_uid = $1`
-	err = tx.GetContext(ctx, &delivery, query, orderUID)
+// scanOrderRow разбирает строку selectOrdersQuery
+func scanOrderRow(row rowScanner) (model.Order, error) {
+	var (
+		order            model.Order
+		internalSig      sql.NullString
+		requestID        sql.NullString
+		validationStatus string
+		itemsJSON        []byte
+	)
+	err := row.Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &internalSig,
+		&order.CustomerID, &order.DeliveryService, &order.ShardKey, &order.SmID, &order.DateCreated,
+		&order.OofShard, &validationStatus, &order.ValidationWarnings, &order.Version,
+		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+		&order.Payment.Transaction, &requestID, &order.Payment.Currency, &order.Payment.Provider,
+		&order.Payment.Amount, &order.Payment.PaymentDT, &order.Payment.Bank,
+		&order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
+		&itemsJSON,
+	)
 	if err != nil {
-		return model.Order{}, fmt.Errorf("failed to get delivery: %w", err)
+		return model.Order{}, err
 	}
-	order.Delivery = delivery
 
-	// Получаем платеж
-	var payment model.Payment
-	query = `SELECT * FROM payment WHERE transaction = $1`
-	err = tx.GetContext(ctx, &payment, query, orderUID)
-	if err != nil {
-		return model.Order{}, fmt.Errorf("failed to get payment: %w", err)
+	order.InternalSignature = internalSig.String
+	order.ValidationStatus = model.ValidationStatus(validationStatus)
+	order.Delivery.OrderUID = order.OrderUID
+	order.Payment.OrderUID = order.OrderUID
+	order.Payment.RequestID = requestID.String
+
+	var items []itemRow
+	if err := json.Unmarshal(itemsJSON, &items); err != nil {
+		return model.Order{}, fmt.Errorf("failed to decode items: %w", err)
+	}
+	for _, it := range items {
+		order.Items = append(order.Items, model.Item{
+			ID:          it.ID,
+			OrderUID:    order.OrderUID,
+			ChrtID:      it.ChrtID,
+			TrackNumber: it.TrackNumber,
+			Price:       it.Price,
+			Rid:         it.Rid,
+			Name:        it.Name,
+			Sale:        it.Sale,
+			Size:        it.Size,
+			TotalPrice:  it.TotalPrice,
+			NmID:        it.NmID,
+			Brand:       it.Brand,
+			Status:      it.Status,
+		})
 	}
-	order.Payment = payment
+	order.BindCurrency()
 
-	// Получаем товары
-	var items []model.Item
-	query = `SELECT * FROM items WHERE order_uid = $1`
-	err = tx.SelectContext(ctx, &items, query, orderUID)
-	if err != nil {
-		return model.Order{}, fmt.Errorf("failed to get items: %w", err)
+	return order, nil
+}
+
+func (r *postgresRepository) ListOrders(ctx context.Context, listing model.OrderListing) ([]model.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
+
+	var after, afterUID any
+	if listing.After != nil {
+		after, afterUID = listing.After.DateCreated, listing.After.OrderUID.String()
 	}
-	order.Items = items
 
-	// Фиксируем транзакцию
-	if err = tx.Commit(); err != nil {
-		return model.Order{}, fmt.Errorf("failed to commit transaction: %w", err)
+	var orders []model.Order
+	err := r.read(ctx, "", func(db *sqlx.DB) error {
+		orders = orders[:0]
+		rows, err := db.QueryContext(ctx, listOrdersQuery, listing.Since, after, afterUID, listing.Limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			order, err := scanOrderRow(rows)
+			if err != nil {
+				return err
+			}
+			orders = append(orders, order)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
 	}
+	return orders, nil
+}
 
-	return order, nil
+// ForEachOrderUID обходит order_keys, где есть и живые, и архивные заказы.
+// Таймаут чтения не применяется: обход всех заказов может быть долгим.
+// Читает всегда primary: заказ, которого нет на отставшей реплике, фильтр
+// существования счёл бы отсутствующим.
+func (r *postgresRepository) ForEachOrderUID(ctx context.Context, fn func(orderUID string) error) error {
+	err := func() error {
+		rows, err := r.db.QueryContext(ctx, `SELECT order_uid FROM order_keys`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var uid string
+			if err := rows.Scan(&uid); err != nil {
+				return err
+			}
+			if err := fn(uid); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}()
+	if err != nil {
+		return fmt.Errorf("failed to list order uids: %w", err)
+	}
+	return nil
 }
 
-func (r *postgresRepository) Store(order *model.Order) error {
-	ctx := context.Background()
+func (r *postgresRepository) Store(ctx context.Context, order *model.Order) (application.StoreResult, error) {
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
 
 	// Начинаем транзакцию
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Сохраняем основной заказ
-	query := `INSERT INTO orders (
+	// Регистрируем order_uid. Уникальность заказа держит order_keys:
+	// на партиционированной orders её не выразить. В режиме upsert
+	// конфликт не ошибка: значит, заказ уже есть и его надо сравнить с новым.
+	query := insertOrderKeyQuery
+	if r.storeMode == StoreModeUpsert {
+		query += ` ON CONFLICT (order_uid) DO NOTHING`
+	}
+	res, err := tx.NamedExecContext(ctx, query, order)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return "", application.ErrOrderExists
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to insert order: %w", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	result, version := application.StoreCreated, 1
+	switch {
+	case inserted == 1 && order.Version != 0:
+		// Клиент ждёт версию заказа (или любую, AnyVersion), которого нет
+		return "", application.ErrVersionConflict
+	case inserted == 1:
+		err = insertOrder(ctx, tx, order)
+	default:
+		result, version, err = updateOrder(ctx, tx, order)
+	}
+	if err != nil {
+		return "", rejectedData(err)
+	}
+	if result == application.StoreUnchanged {
+		order.Version = version
+		return result, nil
+	}
+
+	if err = insertHistory(ctx, tx, order, result); err != nil {
+		return "", rejectedData(err)
+	}
+
+	// Фиксируем транзакцию
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	order.Version = version
+	r.replicas.wrote(order.OrderUID.String())
+
+	return result, nil
+}
+
+const insertOrderKeyQuery = `INSERT INTO order_keys (order_uid, date_created)
+	VALUES (:order_uid, :date_created)`
+
+const insertOrderQuery = `INSERT INTO orders (
 		order_uid, track_number, entry, locale, internal_signature, 
-		customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+		customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard,
+		validation_status, validation_warnings
 	) VALUES (
 		:order_uid, :track_number, :entry, :locale, :internal_signature,
-		:customer_id, :delivery_service, :shardkey, :sm_id, :date_created, :oof_shard
+		:customer_id, :delivery_service, :shardkey, :sm_id, :date_created, :oof_shard,
+		COALESCE(NULLIF(:validation_status, ''), 'valid'), :validation_warnings
 	)`
-	_, err = tx.NamedExecContext(ctx, query, order)
+
+// insertItemsQuery вставляет все товары заказа ($1) из JSON-массива ($2)
+// одним запросом: триггеры order_search срабатывают на запрос, и документ
+// поиска пересобирается один раз, а не на каждый товар. date_created товаров
+// берётся из order_keys, чтобы они попали в ту же партицию, что и заказ.
+const insertItemsQuery = `INSERT INTO items (
+		order_uid, date_created, chrt_id, track_number, price, rid, name,
+		sale, size, total_price, nm_id, brand, status
+	)
+	SELECT k.order_uid, k.date_created, i.chrt_id, i.track_number, i.price, i.rid, i.name,
+		i.sale, i.size, i.total_price, i.nm_id, i.brand, i.status
+	FROM order_keys k, jsonb_to_recordset($2::jsonb) AS i(
+		chrt_id BIGINT, track_number VARCHAR, price BIGINT, rid VARCHAR, name VARCHAR,
+		sale INTEGER, size VARCHAR, total_price BIGINT, nm_id BIGINT, brand VARCHAR, status INTEGER
+	)
+	WHERE k.order_uid = $1`
+
+// insertItems записывает товары заказа одним запросом insertItemsQuery + suffix
+func insertItems(ctx context.Context, tx *sqlx.Tx, orderUID string, items []model.Item, suffix string) error {
+	if items == nil {
+		items = []model.Item{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, insertItemsQuery+suffix, orderUID, data)
+	return err
+}
+
+// insertOrder сохраняет заказ, доставку, платёж и товары нового заказа
+func insertOrder(ctx context.Context, tx *sqlx.Tx, order *model.Order) error {
+	// Сохраняем основной заказ
+	_, err := tx.NamedExecContext(ctx, insertOrderQuery, order)
 	if err != nil {
 		return fmt.Errorf("failed to insert order: %w", err)
 	}
@@ -100,7 +435,7 @@ func (r *postgresRepository) Store(order *model.Order) error {
 	// Сохраняем доставку
 	delivery := order.Delivery
 	delivery.OrderUID = order.OrderUID
-	query = `INSERT INTO delivery (
+	query := `INSERT INTO delivery (
 		order_uid, name, phone, zip, city, address, region, email
 	) VALUES (
 		:order_uid, :name, :phone, :zip, :city, :address, :region, :email
@@ -112,11 +447,12 @@ func (r *postgresRepository) Store(order *model.Order) error {
 
 	// Сохраняем платеж
 	payment := order.Payment
+	payment.OrderUID = order.OrderUID
 	query = `INSERT INTO payment (
-		transaction, request_id, currency, provider, amount, 
+		order_uid, transaction, request_id, currency, provider, amount, 
 		payment_dt, bank, delivery_cost, goods_total, custom_fee
 	) VALUES (
-		:transaction, :request_id, :currency, :provider, :amount,
+		:order_uid, :transaction, :request_id, :currency, :provider, :amount,
 		:payment_dt, :bank, :delivery_cost, :goods_total, :custom_fee
 	)`
 	_, err = tx.NamedExecContext(ctx, query, payment)
@@ -125,25 +461,115 @@ func (r *postgresRepository) Store(order *model.Order) error {
 	}
 
 	// Сохраняем товары
-	for _, item := range order.Items {
-		item.OrderUID = order.OrderUID
-		query = `INSERT INTO items (
-			order_uid, chrt_id, track_number, price, rid, name, 
-			sale, size, total_price, nm_id, brand, status
-		) VALUES (
-			:order_uid, :chrt_id, :track_number, :price, :rid, :name,
-			:sale, :size, :total_price, :nm_id, :brand, :status
-		)`
-		_, err = tx.NamedExecContext(ctx, query, item)
-		if err != nil {
-			return fmt.Errorf("failed to insert item: %w", err)
-		}
+	if err = insertItems(ctx, tx, order.OrderUID.String(), order.Items, ""); err != nil {
+		return fmt.Errorf("failed to insert items: %w", err)
 	}
 
-	// Фиксируем транзакцию
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return nil
+}
+
+// updateOrder сравнивает уже сохранённый заказ с новым и применяет отличия.
+// Возвращает версию заказа после записи.
+func updateOrder(ctx context.Context, tx *sqlx.Tx, order *model.Order) (application.StoreResult, int, error) {
+	// Блокируем заказ, чтобы параллельные upsert-ы применялись по очереди
+	_, err := tx.ExecContext(ctx, `SELECT 1 FROM order_keys WHERE order_uid = $1 FOR UPDATE`, order.OrderUID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to lock order: %w", err)
 	}
 
-	return nil
+	existing, archived, err := getOrder(ctx, tx, order.OrderUID.String())
+	if err != nil {
+		return "", 0, err
+	}
+	if order.Version > 0 && order.Version != existing.Version {
+		return "", 0, application.ErrVersionConflict
+	}
+	if existing.SameContent(order) {
+		return application.StoreUnchanged, existing.Version, nil
+	}
+	if archived {
+		return "", 0, application.ErrOrderArchived
+	}
+
+	// Смена date_created переносит заказ и его товары в другую партицию
+	query := `UPDATE order_keys SET date_created = :date_created WHERE order_uid = :order_uid`
+	if _, err = tx.NamedExecContext(ctx, query, order); err != nil {
+		return "", 0, fmt.Errorf("failed to update order key: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE items SET date_created = $2 WHERE order_uid = $1 AND date_created <> $2`,
+		order.OrderUID, order.DateCreated,
+	)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to move items: %w", err)
+	}
+
+	query = `UPDATE orders SET
+		track_number = :track_number, entry = :entry, locale = :locale,
+		internal_signature = :internal_signature, customer_id = :customer_id,
+		delivery_service = :delivery_service, shardkey = :shardkey, sm_id = :sm_id,
+		date_created = :date_created, oof_shard = :oof_shard,
+		validation_status = COALESCE(NULLIF(:validation_status, ''), 'valid'),
+		validation_warnings = :validation_warnings,
+		version = version + 1
+	WHERE order_uid = :order_uid AND version = :version`
+	// Строка уже заблокирована, но версия всё равно сверяется в UPDATE
+	cas := *order
+	cas.Version = existing.Version
+	res, err := tx.NamedExecContext(ctx, query, &cas)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to update order: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to update order: %w", err)
+	}
+	if updated != 1 {
+		return "", 0, application.ErrVersionConflict
+	}
+
+	delivery := order.Delivery
+	delivery.OrderUID = order.OrderUID
+	query = `UPDATE delivery SET
+		name = :name, phone = :phone, zip = :zip, city = :city,
+		address = :address, region = :region, email = :email
+	WHERE order_uid = :order_uid`
+	if _, err = tx.NamedExecContext(ctx, query, delivery); err != nil {
+		return "", 0, fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	payment := order.Payment
+	payment.OrderUID = order.OrderUID
+	query = `UPDATE payment SET
+		transaction = :transaction, request_id = :request_id, currency = :currency,
+		provider = :provider, amount = :amount, payment_dt = :payment_dt, bank = :bank,
+		delivery_cost = :delivery_cost, goods_total = :goods_total, custom_fee = :custom_fee
+	WHERE order_uid = :order_uid`
+	if _, err = tx.NamedExecContext(ctx, query, payment); err != nil {
+		return "", 0, fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	// Товары сопоставляются по rid: новые вставляются, изменённые
+	// обновляются, пропавшие из заказа удаляются
+	rids := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		rids = append(rids, item.Rid)
+	}
+	err = insertItems(ctx, tx, order.OrderUID.String(), order.Items, ` ON CONFLICT (order_uid, rid, date_created) DO UPDATE SET
+		chrt_id = EXCLUDED.chrt_id, track_number = EXCLUDED.track_number,
+		price = EXCLUDED.price, name = EXCLUDED.name, sale = EXCLUDED.sale,
+		size = EXCLUDED.size, total_price = EXCLUDED.total_price,
+		nm_id = EXCLUDED.nm_id, brand = EXCLUDED.brand, status = EXCLUDED.status`)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to upsert items: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM items WHERE order_uid = $1 AND NOT (rid = ANY($2))`,
+		order.OrderUID, pq.Array(rids),
+	)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to delete items: %w", err)
+	}
+
+	return application.StoreUpdated, existing.Version + 1, nil
 }
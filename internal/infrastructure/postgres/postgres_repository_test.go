@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB подключается к БД из POSTGRES_TEST_DSN (с применёнными миграциями)
+// или пропускает тест, если переменная не задана
+func testDB(tb testing.TB) *sqlx.DB {
+	tb.Helper()
+
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		tb.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		tb.Fatalf("connect: %v", err)
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestRejectedData(t *testing.T) {
+	for code, rejected := range map[pq.ErrorCode]bool{
+		"22003": true,  // numeric_value_out_of_range
+		"23505": true,  // unique_violation
+		"23514": true,  // check_violation
+		"40001": false, // serialization_failure
+		"57014": false, // query_canceled
+	} {
+		err := rejectedData(&pq.Error{Code: code})
+		assert.Equal(t, rejected, errors.Is(err, application.ErrOrderRejected), code)
+	}
+
+	other := errors.New("connection reset")
+	assert.Equal(t, other, rejectedData(other))
+}
+
+func TestOrdersRepositoryContract(t *testing.T) {
+	db := testDB(t)
+
+	contract.TestOrdersRepository(t, func(t *testing.T) application.OrdersRepository {
+		return NewOrdersRepository(db, nil, config.DBConfig{StoreMode: StoreModeUpsert})
+	})
+}
+
+func TestPurgeInboxMessages_ArchivesEveryCopy(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewOrdersRepository(db, nil, config.DBConfig{StoreMode: StoreModeUpsert})
+
+	msg := model.InboxMessage{ID: uuid.NewString(), Key: "archive", Topic: "contract", Payload: "{}"}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, repo.SaveInboxMessage(ctx, msg))
+		require.NoError(t, repo.MarkInboxMessageProcessed(ctx, msg.ID))
+
+		_, err := db.ExecContext(ctx,
+			`UPDATE inbox SET processed_at = processed_at - interval '1 hour' WHERE message_id = $1`, msg.ID)
+		require.NoError(t, err)
+		for {
+			n, err := repo.PurgeInboxMessages(ctx, model.InboxPurge{OlderThan: time.Minute, Limit: 1000, Archive: true})
+			require.NoError(t, err)
+			if n < 1000 {
+				break
+			}
+		}
+	}
+
+	var copies int
+	require.NoError(t, db.GetContext(ctx, &copies, `SELECT COUNT(*) FROM inbox_archive WHERE message_id = $1`, msg.ID))
+	assert.Equal(t, 2, copies)
+
+	var key string
+	require.NoError(t, db.GetContext(ctx, &key, `SELECT message_key FROM inbox_archive WHERE message_id = $1 LIMIT 1`, msg.ID))
+	assert.Equal(t, msg.Key, key)
+}
+
+func TestOrderKeysListener_ReceivesNewOrders(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewOrdersRepository(db, nil, config.DBConfig{StoreMode: StoreModeUpsert})
+
+	added := make(chan string, 16)
+	listener, err := NewOrderKeysListener(config.DBConfig{DSN: os.Getenv("POSTGRES_TEST_DSN")},
+		func(uid string) { added <- uid }, func() {})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	order := contract.NewOrder()
+	_, err = repo.Store(ctx, order)
+	require.NoError(t, err)
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case uid := <-added:
+			if uid == order.OrderUID.String() {
+				return
+			}
+		case <-timeout:
+			t.Fatal("no notification for the stored order")
+		}
+	}
+}
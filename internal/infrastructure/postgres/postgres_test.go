@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDSN(t *testing.T) {
+	cfg := config.DBConfig{
+		Host: "db", Port: 5432, User: "orders", Password: "p@ss word's", Name: "orders",
+		SSLMode:          "verify-full",
+		SSLRootCert:      "/certs/ca.pem",
+		ApplicationName:  "wb-orders-service",
+		StatementTimeout: 2 * time.Second,
+	}
+
+	assert.Equal(t,
+		`host=db port=5432 user=orders password='p@ss word\'s' dbname=orders sslmode=verify-full `+
+			`sslrootcert=/certs/ca.pem application_name=wb-orders-service statement_timeout=2000`,
+		DSN(cfg),
+	)
+}
+
+func TestDSN_Defaults(t *testing.T) {
+	cfg := config.DBConfig{Host: "db", Port: 5432, User: "postgres", Password: "", Name: "orders"}
+
+	// Пустой пароль и sslmode по умолчанию
+	assert.Equal(t, "host=db port=5432 user=postgres password='' dbname=orders sslmode=disable", DSN(cfg))
+}
+
+func TestDSN_Override(t *testing.T) {
+	cfg := config.DBConfig{Host: "ignored", DSN: "postgres://u:p@db:5432/orders?sslmode=require"}
+
+	assert.Equal(t, cfg.DSN, DSN(cfg))
+}
+
+func TestNextBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, nextBackoff(500*time.Millisecond, 10*time.Second))
+	assert.Equal(t, 10*time.Second, nextBackoff(8*time.Second, 10*time.Second))
+	assert.Equal(t, 200*time.Millisecond, nextBackoff(0, 0))
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+type replica struct {
+	name    string
+	db      *sqlx.DB
+	healthy atomic.Bool
+}
+
+// ReplicaSet распределяет чтения заказов по здоровым репликам. Пока ни одна
+// реплика не здорова, чтения идут на primary. Заказы, записанные за
+// последние ReadYourWrites, тоже читаются с primary, чтобы клиент сразу
+// увидел свою запись. Nil *ReplicaSet — реплик нет.
+type ReplicaSet struct {
+	cfg      config.ReplicaConfig
+	replicas []*replica
+	next     atomic.Uint64
+
+	mu     sync.Mutex
+	recent map[string]time.Time
+}
+
+// NewReplicaSet открывает пулы реплик из cfg.Replicas.DSNs. Соединения
+// устанавливаются лениво: недоступная при старте реплика не мешает запуску,
+// её состояние определяет health check. Без реплик возвращает nil.
+func NewReplicaSet(cfg config.DBConfig) (*ReplicaSet, error) {
+	rc := cfg.Replicas
+	if len(rc.DSNs) == 0 {
+		return nil, nil
+	}
+	if rc.HealthCheckInterval <= 0 {
+		rc.HealthCheckInterval = 5 * time.Second
+	}
+	if rc.HealthCheckTimeout <= 0 {
+		rc.HealthCheckTimeout = time.Second
+	}
+	if window := readYourWritesWindow(rc); window != rc.ReadYourWrites {
+		logger.Log.Warnf(
+			"read_your_writes %s is shorter than max_lag + health_check_interval, using %s",
+			rc.ReadYourWrites, window,
+		)
+		rc.ReadYourWrites = window
+	}
+
+	s := &ReplicaSet{
+		cfg:    rc,
+		recent: make(map[string]time.Time),
+	}
+	for i, dsn := range rc.DSNs {
+		db, err := sqlx.Open("postgres", dsn)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		configurePool(db, cfg.Pool)
+		s.replicas = append(s.replicas, &replica{name: fmt.Sprintf("replica-%d", i), db: db})
+	}
+
+	return s, nil
+}
+
+// readYourWritesWindow — окно read-your-writes не короче отставания, с
+// которым реплика ещё может получать чтения: до MaxLag на момент проверки и
+// ещё HealthCheckInterval до следующей. Вне окна реплика уже содержит запись,
+// и прочитанный с неё заказ можно класть в кэш.
+func readYourWritesWindow(rc config.ReplicaConfig) time.Duration {
+	if rc.MaxLag <= 0 {
+		return rc.ReadYourWrites
+	}
+	return max(rc.ReadYourWrites, rc.MaxLag+rc.HealthCheckInterval)
+}
+
+// Start проверяет реплики сразу и затем каждые HealthCheckInterval
+func (s *ReplicaSet) Start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+
+	s.check(ctx)
+	go func() {
+		ticker := time.NewTicker(s.cfg.HealthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.check(ctx)
+				s.forgetWrites(time.Now())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (s *ReplicaSet) Close() {
+	if s == nil {
+		return
+	}
+	for _, r := range s.replicas {
+		r.db.Close()
+	}
+}
+
+func (s *ReplicaSet) check(ctx context.Context) {
+	for _, r := range s.replicas {
+		err := s.probe(ctx, r)
+		s.setHealthy(r, err)
+	}
+}
+
+// probe проверяет доступность реплики и её отставание от primary
+func (s *ReplicaSet) probe(ctx context.Context, r *replica) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.HealthCheckTimeout)
+	defer cancel()
+
+	// Отставание считается нулевым, если всё полученное уже применено:
+	// иначе реплика простаивающего primary выглядела бы отстающей
+	var lag float64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&lag)
+	if err != nil {
+		return err
+	}
+
+	if s.cfg.MaxLag > 0 && time.Duration(lag*float64(time.Second)) > s.cfg.MaxLag {
+		return fmt.Errorf("replication lag %.1fs exceeds %s", lag, s.cfg.MaxLag)
+	}
+	return nil
+}
+
+func (s *ReplicaSet) setHealthy(r *replica, err error) {
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		logger.Log.Infof("postgres %s is up, serving reads", r.name)
+	} else {
+		logger.Log.Warnf("postgres %s is down, reads fall back: %v", r.name, err)
+	}
+}
+
+// reader выбирает реплику для чтения заказа или nil, если читать надо с primary
+func (s *ReplicaSet) reader(orderUID string) *replica {
+	if s == nil || len(s.replicas) == 0 || s.recentlyWritten(orderUID) {
+		return nil
+	}
+
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// wrote отмечает запись заказа для read-your-writes
+func (s *ReplicaSet) wrote(orderUID string) {
+	if s == nil || s.cfg.ReadYourWrites <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.recent[orderUID] = time.Now().Add(s.cfg.ReadYourWrites)
+	s.mu.Unlock()
+}
+
+func (s *ReplicaSet) recentlyWritten(orderUID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.recent[orderUID]
+	return ok && time.Now().Before(until)
+}
+
+// forgetWrites удаляет истёкшие отметки read-your-writes
+func (s *ReplicaSet) forgetWrites(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uid, until := range s.recent {
+		if !now.Before(until) {
+			delete(s.recent, uid)
+		}
+	}
+}
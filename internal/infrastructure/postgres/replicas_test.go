@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func testReplicaSet(n int, readYourWrites time.Duration) *ReplicaSet {
+	s := &ReplicaSet{
+		cfg:    config.ReplicaConfig{ReadYourWrites: readYourWrites},
+		recent: make(map[string]time.Time),
+	}
+	for i := 0; i < n; i++ {
+		s.replicas = append(s.replicas, &replica{db: sqlx.NewDb(&sql.DB{}, "postgres")})
+	}
+	return s
+}
+
+func TestReplicaSet_NilReadsPrimary(t *testing.T) {
+	var s *ReplicaSet
+
+	assert.Nil(t, s.reader("uid"))
+	s.wrote("uid")
+}
+
+func TestReplicaSet_FallsBackWhenAllDown(t *testing.T) {
+	s := testReplicaSet(2, 0)
+
+	assert.Nil(t, s.reader("uid"))
+
+	s.setHealthy(s.replicas[1], nil)
+	assert.Same(t, s.replicas[1], s.reader("uid"))
+
+	s.setHealthy(s.replicas[1], errors.New("connection refused"))
+	assert.Nil(t, s.reader("uid"))
+}
+
+func TestReplicaSet_RoundRobin(t *testing.T) {
+	s := testReplicaSet(2, 0)
+	for _, r := range s.replicas {
+		s.setHealthy(r, nil)
+	}
+
+	first, second := s.reader("uid"), s.reader("uid")
+	assert.NotSame(t, first, second)
+}
+
+func TestReplicaSet_ReadYourWrites(t *testing.T) {
+	s := testReplicaSet(1, time.Minute)
+	s.setHealthy(s.replicas[0], nil)
+
+	s.wrote("written")
+
+	// Только что записанный заказ читается с primary, остальные — с реплики
+	assert.Nil(t, s.reader("written"))
+	assert.NotNil(t, s.reader("other"))
+
+	s.forgetWrites(time.Now().Add(2 * time.Minute))
+	assert.NotNil(t, s.reader("written"))
+}
+
+func TestReadYourWritesWindow(t *testing.T) {
+	rc := config.ReplicaConfig{
+		HealthCheckInterval: 5 * time.Second,
+		MaxLag:              10 * time.Second,
+		ReadYourWrites:      5 * time.Second,
+	}
+	assert.Equal(t, 15*time.Second, readYourWritesWindow(rc))
+
+	rc.ReadYourWrites = time.Minute
+	assert.Equal(t, time.Minute, readYourWritesWindow(rc))
+
+	// без max_lag отставание не ограничено, окно остаётся как задано
+	rc.MaxLag = 0
+	rc.ReadYourWrites = 5 * time.Second
+	assert.Equal(t, 5*time.Second, readYourWritesWindow(rc))
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// Документ поиска order_search поддерживают триггеры на items и delivery
+// (миграция 009). Текст запроса уходит в websearch_to_tsquery параметром и
+// в SQL не подставляется.
+
+const countOrderSearchQuery = `
+	SELECT count(*)
+	FROM order_search
+	WHERE document @@ websearch_to_tsquery('english', $1)`
+
+// searchOrdersQuery берёт сводку заказа из живых или архивных партиций:
+// дата из order_keys оставляет по одной партиции в каждой
+const searchOrdersQuery = `
+	WITH matches AS (
+		SELECT s.order_uid, ts_rank_cd(s.document, q) AS rank
+		FROM order_search s, websearch_to_tsquery('english', $1) q
+		WHERE s.document @@ q
+	)
+	SELECT
+		m.order_uid, k.date_created, o.track_number, o.customer_id,
+		d.name AS delivery_name, d.city AS delivery_city, m.rank
+	FROM matches m
+	JOIN order_keys k ON k.order_uid = m.order_uid
+	JOIN delivery d ON d.order_uid = m.order_uid
+	JOIN LATERAL (
+		SELECT track_number, customer_id FROM orders
+		WHERE order_uid = m.order_uid AND date_created = k.date_created
+		UNION ALL
+		SELECT track_number, customer_id FROM orders_archive
+		WHERE order_uid = m.order_uid AND date_created = k.date_created
+		LIMIT 1
+	) o ON true
+	ORDER BY m.rank DESC, k.date_created DESC, m.order_uid
+	LIMIT $2 OFFSET $3`
+
+func (r *postgresRepository) SearchOrders(ctx context.Context, search model.OrderSearch) (model.OrderSearchResult, error) {
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
+
+	var (
+		result model.OrderSearchResult
+		hits   []model.OrderSearchHit
+	)
+	err := r.read(ctx, "", func(db *sqlx.DB) error {
+		hits = hits[:0]
+		if err := db.GetContext(ctx, &result.Total, countOrderSearchQuery, search.Query); err != nil {
+			return err
+		}
+		if result.Total == 0 {
+			return nil
+		}
+
+		rows, err := db.QueryContext(ctx, searchOrdersQuery, search.Query, search.Limit, search.Offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var hit model.OrderSearchHit
+			if err := rows.Scan(
+				&hit.OrderUID, &hit.DateCreated, &hit.TrackNumber, &hit.CustomerID,
+				&hit.DeliveryName, &hit.DeliveryCity, &hit.Rank,
+			); err != nil {
+				return err
+			}
+			hits = append(hits, hit)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return model.OrderSearchResult{}, fmt.Errorf("failed to search orders: %w", err)
+	}
+
+	result.Hits = hits
+	return result, nil
+}
@@ -0,0 +1,220 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// formatVersion — версия формата записи в кэше. Увеличивается при
+// несовместимом изменении model.Order: записи старой версии читаются как
+// промах, а не расшифровываются в неверные данные. Набор полей заказа
+// закреплён тестом TestFormatVersionPinsOrderFields.
+const formatVersion byte = 1
+
+const headerSize = 3
+
+// Второй байт заголовка — кодировка значения
+const (
+	encodingJSON byte = iota
+	encodingMsgpack
+	encodingGob
+
+	// encodingNotFound — заказа нет в БД, тела нет
+	encodingNotFound byte = 0xff
+)
+
+// Третий байт заголовка — сжатие тела
+const (
+	compressionNone byte = iota
+	compressionGzip
+)
+
+var encodings = map[string]byte{
+	"json":    encodingJSON,
+	"msgpack": encodingMsgpack,
+	"gob":     encodingGob,
+}
+
+var compressions = map[string]byte{
+	"none": compressionNone,
+	"gzip": compressionGzip,
+}
+
+// errUndecodable — запись другой версии или повреждена
+var errUndecodable = errors.New("undecodable cache entry")
+
+// codec кодирует заказы для Redis: заголовок [версия, кодировка, сжатие] и
+// тело. Читает записи в любой кодировке и сжатии текущей версии, поэтому
+// смена encoding или compression не сбрасывает кэш. Нулевое значение — JSON
+// без сжатия.
+type codec struct {
+	encoding        byte
+	compression     byte
+	compressMinSize int
+}
+
+func newCodec(cfg config.CodecConfig) (codec, error) {
+	var c codec
+
+	if cfg.Encoding != "" {
+		enc, ok := encodings[cfg.Encoding]
+		if !ok {
+			return codec{}, fmt.Errorf("unknown cache encoding %q", cfg.Encoding)
+		}
+		c.encoding = enc
+	}
+	if cfg.Compression != "" {
+		comp, ok := compressions[cfg.Compression]
+		if !ok {
+			return codec{}, fmt.Errorf("unknown cache compression %q", cfg.Compression)
+		}
+		c.compression = comp
+	}
+	c.compressMinSize = cfg.CompressMinSize
+
+	return c, nil
+}
+
+func (c codec) encode(order *model.Order) ([]byte, error) {
+	order = publicOrder(order)
+
+	var body bytes.Buffer
+	var err error
+	switch c.encoding {
+	case encodingMsgpack:
+		err = msgpack.NewEncoder(&body).Encode(order)
+	case encodingGob:
+		err = gob.NewEncoder(&body).Encode(order)
+	default:
+		err = json.NewEncoder(&body).Encode(order)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	compression := compressionNone
+	if c.compression == compressionGzip && body.Len() >= c.compressMinSize {
+		compression = compressionGzip
+	}
+
+	data := make([]byte, headerSize, headerSize+body.Len())
+	data[0], data[1], data[2] = formatVersion, c.encoding, compression
+
+	if compression == compressionNone {
+		return append(data, body.Bytes()...), nil
+	}
+
+	out := bytes.NewBuffer(data)
+	zw, err := gzip.NewWriterLevel(out, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// publicOrder — копия заказа без полей с тегом json:"-". JSON их не пишет,
+// а msgpack и gob записали бы, поэтому все кодировки кодируют эту копию и
+// хранят один набор полей. Валюту сумм JSON тоже не пишет, её
+// восстанавливает BindCurrency при чтении.
+func publicOrder(order *model.Order) *model.Order {
+	public := *order
+	clearHidden(reflect.ValueOf(&public).Elem())
+	return &public
+}
+
+// clearHidden обнуляет поля с тегом json:"-" в v и во вложенных структурах.
+// Срезы копируются, чтобы не менять заказ вызывающего.
+func clearHidden(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			switch {
+			case !f.IsExported():
+			case f.Tag.Get("json") == "-":
+				v.Field(i).SetZero()
+			default:
+				clearHidden(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() != reflect.Struct {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		v.Set(cp)
+		for i := range cp.Len() {
+			clearHidden(cp.Index(i))
+		}
+	}
+}
+
+// encodeNotFound — запись об отсутствующем заказе
+func (c codec) encodeNotFound() []byte {
+	return []byte{formatVersion, encodingNotFound, compressionNone}
+}
+
+// decode возвращает application.ErrOrderNotFound для записи об отсутствующем
+// заказе и errUndecodable для записей, которые не удалось прочитать
+func (c codec) decode(data []byte) (model.Order, error) {
+	if len(data) < headerSize || data[0] != formatVersion {
+		return model.Order{}, errUndecodable
+	}
+	encoding, compression, body := data[1], data[2], data[headerSize:]
+
+	if encoding == encodingNotFound {
+		return model.Order{}, application.ErrOrderNotFound
+	}
+
+	var r io.Reader = bytes.NewReader(body)
+	switch compression {
+	case compressionNone:
+	case compressionGzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return model.Order{}, fmt.Errorf("%w: %v", errUndecodable, err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return model.Order{}, errUndecodable
+	}
+
+	var order model.Order
+	var err error
+	switch encoding {
+	case encodingJSON:
+		err = json.NewDecoder(r).Decode(&order)
+	case encodingMsgpack:
+		err = msgpack.NewDecoder(r).Decode(&order)
+	case encodingGob:
+		err = gob.NewDecoder(r).Decode(&order)
+	default:
+		return model.Order{}, errUndecodable
+	}
+	if err != nil {
+		return model.Order{}, fmt.Errorf("%w: %v", errUndecodable, err)
+	}
+	order.BindCurrency()
+
+	return order, nil
+}
@@ -0,0 +1,224 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecReadsOtherEncodings(t *testing.T) {
+	order := contract.NewOrder()
+
+	for _, cfg := range []config.CodecConfig{
+		{Encoding: "json"},
+		{Encoding: "msgpack", Compression: "gzip"},
+		{Encoding: "gob", Compression: "gzip", CompressMinSize: 1 << 20},
+	} {
+		writer, err := newCodec(cfg)
+		require.NoError(t, err)
+
+		data, err := writer.encode(order)
+		require.NoError(t, err)
+
+		got, err := codec{}.decode(data)
+		require.NoError(t, err, cfg.Encoding)
+		contract.AssertPublicOrderEqual(t, order, &got)
+	}
+}
+
+func TestCodecCompressesOnlyLargeValues(t *testing.T) {
+	order := contract.NewOrder()
+
+	small, err := newCodec(config.CodecConfig{Encoding: "json", Compression: "gzip", CompressMinSize: 1 << 20})
+	require.NoError(t, err)
+	data, err := small.encode(order)
+	require.NoError(t, err)
+	assert.Equal(t, compressionNone, data[2])
+
+	large, err := newCodec(config.CodecConfig{Encoding: "json", Compression: "gzip"})
+	require.NoError(t, err)
+	data, err = large.encode(order)
+	require.NoError(t, err)
+	assert.Equal(t, compressionGzip, data[2])
+}
+
+func TestNewCodecRejectsUnknownSettings(t *testing.T) {
+	_, err := newCodec(config.CodecConfig{Encoding: "xml"})
+	assert.Error(t, err)
+
+	_, err = newCodec(config.CodecConfig{Compression: "lz4"})
+	assert.Error(t, err)
+}
+
+func TestUndecodableEntryIsMiss(t *testing.T) {
+	ctx := context.Background()
+	order := contract.NewOrder()
+	uid := order.OrderUID.String()
+
+	legacy, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	current, err := codec{}.encode(order)
+	require.NoError(t, err)
+	otherVersion := append([]byte{formatVersion + 1}, current[1:]...)
+
+	for name, value := range map[string][]byte{
+		"legacy json":     legacy,
+		"other version":   otherVersion,
+		"truncated":       current[:len(current)/2],
+		"header only":     current[:headerSize],
+		"unknown codec":   append([]byte{formatVersion, 0x7f, compressionNone}, current[headerSize:]...),
+		"broken compress": append([]byte{formatVersion, encodingJSON, compressionGzip}, current[headerSize:]...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := miniredis.RunT(t)
+			cache := &redisCache{
+				client:    redis.NewClient(&redis.Options{Addr: srv.Addr()}),
+				namespace: "orders:test:",
+				ttl:       time.Minute,
+			}
+			require.NoError(t, srv.Set("orders:test:"+uid, string(value)))
+
+			_, err := cache.GetOrderFromCache(ctx, uid)
+			assert.ErrorIs(t, err, application.ErrCacheMiss)
+		})
+	}
+}
+
+func TestCodecEncodingsKeepSameFields(t *testing.T) {
+	order := contract.NewOrder()
+	order.Items[0].ID = 7
+
+	var decoded []model.Order
+	for _, encoding := range []string{"json", "msgpack", "gob"} {
+		c, err := newCodec(config.CodecConfig{Encoding: encoding})
+		require.NoError(t, err)
+
+		data, err := c.encode(order)
+		require.NoError(t, err)
+		got, err := c.decode(data)
+		require.NoError(t, err, encoding)
+		decoded = append(decoded, got)
+	}
+
+	// Скрытые из JSON поля не попадают в кэш ни в одной кодировке
+	for i, got := range decoded {
+		assert.Empty(t, got.ValidationStatus, i)
+		assert.Empty(t, got.ValidationWarnings, i)
+		assert.Zero(t, got.Items[0].ID, i)
+		contract.AssertPublicOrderEqual(t, &decoded[0], &got)
+	}
+	// Заказ вызывающего не меняется
+	assert.Equal(t, 7, order.Items[0].ID)
+	assert.NotEmpty(t, order.ValidationWarnings)
+}
+
+// cachedOrderFields — поля model.Order в кэше для каждой formatVersion
+var cachedOrderFields = map[byte][]string{
+	1: {
+		"OrderUID uuid.UUID json:order_uid",
+		"TrackNumber string json:track_number",
+		"Entry string json:entry",
+		"Delivery model.Delivery json:delivery",
+		"Delivery.OrderUID uuid.UUID json:-",
+		"Delivery.Name string json:name",
+		"Delivery.Phone string json:phone",
+		"Delivery.Zip string json:zip",
+		"Delivery.City string json:city",
+		"Delivery.Address string json:address",
+		"Delivery.Region string json:region",
+		"Delivery.Email string json:email",
+		"Payment model.Payment json:payment",
+		"Payment.OrderUID uuid.UUID json:-",
+		"Payment.Transaction uuid.UUID json:transaction",
+		"Payment.RequestID string json:request_id",
+		"Payment.Currency string json:currency",
+		"Payment.Provider string json:provider",
+		"Payment.Amount model.Money json:amount",
+		"Payment.Amount.Minor int64 json:",
+		"Payment.Amount.Currency string json:",
+		"Payment.PaymentDT time.Time json:payment_dt",
+		"Payment.Bank string json:bank",
+		"Payment.DeliveryCost model.Money json:delivery_cost",
+		"Payment.DeliveryCost.Minor int64 json:",
+		"Payment.DeliveryCost.Currency string json:",
+		"Payment.GoodsTotal model.Money json:goods_total",
+		"Payment.GoodsTotal.Minor int64 json:",
+		"Payment.GoodsTotal.Currency string json:",
+		"Payment.CustomFee model.Money json:custom_fee",
+		"Payment.CustomFee.Minor int64 json:",
+		"Payment.CustomFee.Currency string json:",
+		"Items []model.Item json:items",
+		"Items[].ID int json:-",
+		"Items[].OrderUID uuid.UUID json:-",
+		"Items[].ChrtID int json:chrt_id",
+		"Items[].TrackNumber string json:track_number",
+		"Items[].Price model.Money json:price",
+		"Items[].Price.Minor int64 json:",
+		"Items[].Price.Currency string json:",
+		"Items[].Rid string json:rid",
+		"Items[].Name string json:name",
+		"Items[].Sale int json:sale",
+		"Items[].Size string json:size",
+		"Items[].TotalPrice model.Money json:total_price",
+		"Items[].TotalPrice.Minor int64 json:",
+		"Items[].TotalPrice.Currency string json:",
+		"Items[].NmID int json:nm_id",
+		"Items[].Brand string json:brand",
+		"Items[].Status int json:status",
+		"Locale string json:locale",
+		"InternalSignature string json:internal_signature",
+		"CustomerID string json:customer_id",
+		"DeliveryService string json:delivery_service",
+		"ShardKey string json:shardkey",
+		"SmID int json:sm_id",
+		"DateCreated time.Time json:date_created",
+		"OofShard string json:oof_shard",
+		"ValidationStatus model.ValidationStatus json:-",
+		"ValidationWarnings model.FieldErrors json:-",
+		"Version int json:version,omitempty",
+	},
+}
+
+// TestFormatVersionPinsOrderFields падает при любом изменении полей
+// model.Order. Если изменение несовместимо с записями в кэше (поле
+// переименовано, удалено или сменило тип), увеличьте formatVersion и
+// добавьте список для новой версии, иначе обновите список текущей.
+func TestFormatVersionPinsOrderFields(t *testing.T) {
+	assert.Equal(t, cachedOrderFields[formatVersion], orderFields(reflect.TypeOf(model.Order{}), ""))
+}
+
+// orderFields перечисляет поля типа и вложенных структур пакета model.
+// Внутрь полей с json:"-" не спускается: в кэш они не попадают.
+func orderFields(t reflect.Type, prefix string) []string {
+	var fields []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name := prefix + f.Name
+		fields = append(fields, fmt.Sprintf("%s %s json:%s", name, f.Type, f.Tag.Get("json")))
+		if f.Tag.Get("json") == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Slice {
+			ft, name = ft.Elem(), name+"[]"
+		}
+		if ft.Kind() == reflect.Struct && ft.PkgPath() == reflect.TypeOf(model.Order{}).PkgPath() {
+			fields = append(fields, orderFields(ft, name+".")...)
+		}
+	}
+	return fields
+}
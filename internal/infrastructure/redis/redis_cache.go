@@ -2,104 +2,164 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"time"
 
 	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
 	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
 	"github.com/go-redis/redis/v8"
-	"github.com/jmoiron/sqlx"
 )
 
+// cacheMetrics публикуется в /debug/vars
+var cacheMetrics = expvar.NewMap("redis_cache")
+
 type redisCache struct {
-	client *redis.Client
-	db     *sqlx.DB
-	ttl    time.Duration
+	client       *redis.Client
+	source       application.OrdersRepository
+	namespace    string
+	codec        codec
+	ttl          time.Duration
+	negativeTTL  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	warmUp       config.WarmUpConfig
 }
 
-type RedisConfig struct {
-	Host     string        `yaml:"host"`
-	Port     int           `yaml:"port"`
-	Password string        `yaml:"password"`
-	DB       int           `yaml:"db"`
-	TTL      time.Duration `yaml:"ttl"`
-}
+// NewRedisCache подключается к Redis. negativeTTL — сколько помнить, что
+// заказа нет; 0 — не запоминать. Прогрев из source запускает вызывающий код
+// через WarmUp.
+func NewRedisCache(ctx context.Context, cfg config.RedisConfig, negativeTTL time.Duration, source application.OrdersRepository) (application.Cacher, error) {
+	codec, err := newCodec(cfg.Codec)
+	if err != nil {
+		return nil, err
+	}
 
-func NewRedisCache(cfg RedisConfig, db *sqlx.DB) (application.Cacher, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		Password: cfg.Password,
 		DB:       cfg.DB,
 	})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if _, err := client.Ping(ctx).Result(); err != nil {
+	if _, err := client.Ping(pingCtx).Result(); err != nil {
 		return nil, fmt.Errorf("redis connection failed: %w", err)
 	}
 
-	cache := &redisCache{
-		client: client,
-		db:     db,
-		ttl:    cfg.TTL,
+	return &redisCache{
+		client:       client,
+		source:       source,
+		namespace:    cfg.Namespace,
+		codec:        codec,
+		ttl:          cfg.TTL,
+		negativeTTL:  negativeTTL,
+		readTimeout:  cfg.ReadTimeout,
+		writeTimeout: cfg.WriteTimeout,
+		warmUp:       cfg.WarmUp,
+	}, nil
+}
+
+func (r *redisCache) Cache(ctx context.Context, order *model.Order) error {
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	data, err := r.codec.encode(order)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
 	}
 
-	if err := cache.WarmUp(); err != nil {
-		return nil, fmt.Errorf("cache warmup failed: %w", err)
+	if err := r.client.Set(ctx, r.key(order.OrderUID.String()), data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("redis set error: %w", err)
 	}
 
-	return cache, nil
+	return nil
 }
 
-func (r *redisCache) WarmUp() error {
-	ctx := context.Background()
+func (r *redisCache) CacheIfAbsent(ctx context.Context, order *model.Order) error {
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
 
-	// Получаем только 1000 последних заказов
-	var orders []model.Order
-	query := `SELECT * FROM orders ORDER BY date_created DESC LIMIT 1000`
-	err := r.db.SelectContext(ctx, &orders, query)
+	data, err := r.codec.encode(order)
 	if err != nil {
-		return fmt.Errorf("db query error: %w", err)
+		return fmt.Errorf("marshal error: %w", err)
 	}
 
-	for _, order := range orders {
-		if err := r.Cache(&order); err != nil {
-			return fmt.Errorf("caching error: %w", err)
-		}
+	if err := r.client.SetNX(ctx, r.key(order.OrderUID.String()), data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("redis setnx error: %w", err)
 	}
 
 	return nil
 }
 
-func (r *redisCache) Cache(order *model.Order) error {
-	ctx := context.Background()
+func (r *redisCache) CacheNotFound(ctx context.Context, orderUID string) error {
+	if r.negativeTTL <= 0 {
+		return nil
+	}
 
-	data, err := json.Marshal(order)
-	if err != nil {
-		return fmt.Errorf("marshal error: %w", err)
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	if err := r.client.SetNX(ctx, r.key(orderUID), r.codec.encodeNotFound(), r.negativeTTL).Err(); err != nil {
+		return fmt.Errorf("redis setnx error: %w", err)
 	}
 
-	if err := r.client.Set(ctx, order.OrderUID.String(), data, r.ttl).Err(); err != nil {
-		return fmt.Errorf("redis set error: %w", err)
+	return nil
+}
+
+func (r *redisCache) Invalidate(ctx context.Context, orderUID string) error {
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	if err := r.client.Del(ctx, r.key(orderUID)).Err(); err != nil {
+		return fmt.Errorf("redis del error: %w", err)
 	}
 
 	return nil
 }
 
-func (r *redisCache) GetOrderFromCache(orderUID string) (model.Order, error) {
-	ctx := context.Background()
+func (r *redisCache) GetOrderFromCache(ctx context.Context, orderUID string) (model.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
 
-	data, err := r.client.Get(ctx, orderUID).Bytes()
+	data, err := r.client.Get(ctx, r.key(orderUID)).Bytes()
+	if err == redis.Nil {
+		return model.Order{}, application.ErrCacheMiss
+	}
 	if err != nil {
 		return model.Order{}, fmt.Errorf("redis get error: %w", err)
 	}
 
-	var order model.Order
-	if err := json.Unmarshal(data, &order); err != nil {
-		return model.Order{}, fmt.Errorf("unmarshal error: %w", err)
+	order, err := r.codec.decode(data)
+	if errors.Is(err, errUndecodable) {
+		// Запись другой версии формата удаляется, чтобы её заменило
+		// чтение из БД: заполнение кэша не перезаписывает существующие ключи
+		cacheMetrics.Add("undecodable", 1)
+		logger.Log.Debugf("Cache entry for order %s is skipped: %v", orderUID, err)
+		if err := r.client.Del(ctx, r.key(orderUID)).Err(); err != nil {
+			logger.Log.Debugf("Failed to drop undecodable cache entry for order %s: %v", orderUID, err)
+		}
+		return model.Order{}, application.ErrCacheMiss
+	}
+	if err != nil {
+		return model.Order{}, err
 	}
 
 	return order, nil
 }
+
+func (r *redisCache) key(orderUID string) string {
+	return r.namespace + orderUID
+}
+
+// withTimeout ограничивает операцию таймаутом из конфига, если он задан
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application"
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisCacheContract_Miniredis(t *testing.T) {
+	for _, cfg := range []config.CodecConfig{
+		{Encoding: "json", Compression: "none"},
+		{Encoding: "msgpack", Compression: "none"},
+		{Encoding: "gob", Compression: "none"},
+		{Encoding: "msgpack", Compression: "gzip"},
+	} {
+		t.Run(cfg.Encoding+"/"+cfg.Compression, func(t *testing.T) {
+			codec, err := newCodec(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contract.TestCacher(t, func(t *testing.T) application.Cacher {
+				srv := miniredis.RunT(t)
+				return &redisCache{
+					client:      redis.NewClient(&redis.Options{Addr: srv.Addr()}),
+					namespace:   "orders:test:",
+					codec:       codec,
+					ttl:         time.Minute,
+					negativeTTL: time.Second,
+				}
+			})
+		})
+	}
+}
+
+// Реальный Redis: REDIS_TEST_DSN="redis://:redis@localhost:6379/0"
+func TestRedisCacheContract_Redis(t *testing.T) {
+	dsn := os.Getenv("REDIS_TEST_DSN")
+	if dsn == "" {
+		t.Skip("REDIS_TEST_DSN is not set")
+	}
+
+	opts, err := redis.ParseURL(dsn)
+	if err != nil {
+		t.Fatalf("parse REDIS_TEST_DSN: %v", err)
+	}
+
+	contract.TestCacher(t, func(t *testing.T) application.Cacher {
+		client := redis.NewClient(opts)
+		t.Cleanup(func() { client.Close() })
+		return &redisCache{client: client, ttl: time.Minute, negativeTTL: time.Second}
+	})
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/pkg/logger"
+	"github.com/go-redis/redis/v8"
+)
+
+// WarmUp загружает последние заказы целиком страницами по BatchSize и
+// записывает каждую страницу в Redis одним pipeline.
+func (r *redisCache) WarmUp(ctx context.Context) error {
+	cfg := r.warmUp
+	if cfg.Count <= 0 {
+		return nil
+	}
+	batch := cfg.BatchSize
+	if batch <= 0 || batch > cfg.Count {
+		batch = cfg.Count
+	}
+
+	listing := model.OrderListing{Limit: batch}
+	if cfg.MaxAge > 0 {
+		listing.Since = time.Now().Add(-cfg.MaxAge)
+	}
+
+	start := time.Now()
+	loaded, cached := 0, 0
+	for loaded < cfg.Count {
+		listing.Limit = min(batch, cfg.Count-loaded)
+		orders, err := r.source.ListOrders(ctx, listing)
+		if err != nil {
+			return fmt.Errorf("failed to load orders: %w", err)
+		}
+		if len(orders) == 0 {
+			break
+		}
+
+		n, err := r.cacheBatch(ctx, orders)
+		if err != nil {
+			return err
+		}
+		loaded += len(orders)
+		cached += n
+		logger.Log.Infof("Cache warm-up: %d/%d orders loaded, %d cached", loaded, cfg.Count, cached)
+
+		if len(orders) < listing.Limit {
+			break
+		}
+		listing.After = orders[len(orders)-1].Cursor()
+	}
+
+	logger.Log.Infof("Cache warm-up finished: %d orders in %s", cached, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// cacheBatch кэширует страницу и возвращает, сколько заказов записано.
+// Заказы на карантине пропускаются, как и в OrdersService, а уже лежащие в
+// кэше записи не заменяются: их мог записать работающий экземпляр сервиса.
+func (r *redisCache) cacheBatch(ctx context.Context, orders []model.Order) (int, error) {
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	cached := 0
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := range orders {
+			if orders[i].ValidationStatus == model.ValidationQuarantined {
+				continue
+			}
+			data, err := r.codec.encode(&orders[i])
+			if err != nil {
+				return fmt.Errorf("marshal error: %w", err)
+			}
+			pipe.SetNX(ctx, r.key(orders[i].OrderUID.String()), data, r.ttl)
+			cached++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("redis pipeline error: %w", err)
+	}
+	return cached, nil
+}
@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Babushkin05/wb-orders-service/internal/application/contract"
+	"github.com/Babushkin05/wb-orders-service/internal/domain/model"
+	"github.com/Babushkin05/wb-orders-service/internal/infrastructure/memory"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmUpCachesCompleteOrders(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewOrdersRepository()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	orders := make([]*model.Order, 5)
+	for i := range orders {
+		orders[i] = contract.NewOrder()
+		orders[i].DateCreated = now.Add(-time.Duration(i) * time.Hour)
+		_, err := repo.Store(ctx, orders[i])
+		require.NoError(t, err)
+	}
+
+	srv := miniredis.RunT(t)
+	cache := &redisCache{
+		client:    redis.NewClient(&redis.Options{Addr: srv.Addr()}),
+		source:    repo,
+		namespace: "orders:test:",
+		ttl:       time.Minute,
+	}
+	cache.warmUp.Count = 3
+	cache.warmUp.BatchSize = 2
+
+	require.NoError(t, cache.WarmUp(ctx))
+
+	assert.Len(t, srv.Keys(), 3)
+	for _, want := range orders[:3] {
+		got, err := cache.GetOrderFromCache(ctx, want.OrderUID.String())
+		require.NoError(t, err)
+		contract.AssertPublicOrderEqual(t, want, &got)
+	}
+}
+
+func TestWarmUpRespectsMaxAge(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewOrdersRepository()
+
+	fresh, stale := contract.NewOrder(), contract.NewOrder()
+	fresh.DateCreated = time.Now().Add(-time.Hour)
+	stale.DateCreated = time.Now().Add(-48 * time.Hour)
+	for _, o := range []*model.Order{fresh, stale} {
+		_, err := repo.Store(ctx, o)
+		require.NoError(t, err)
+	}
+
+	srv := miniredis.RunT(t)
+	cache := &redisCache{
+		client:    redis.NewClient(&redis.Options{Addr: srv.Addr()}),
+		source:    repo,
+		namespace: "orders:test:",
+		ttl:       time.Minute,
+	}
+	cache.warmUp.Count = 100
+	cache.warmUp.MaxAge = 24 * time.Hour
+
+	require.NoError(t, cache.WarmUp(ctx))
+
+	assert.Equal(t, []string{"orders:test:" + fresh.OrderUID.String()}, srv.Keys())
+}
+
+func TestWarmUpSkipsQuarantinedAndKeepsNewer(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewOrdersRepository()
+
+	valid, quarantined := contract.NewOrder(), contract.NewOrder()
+	quarantined.ValidationStatus = model.ValidationQuarantined
+	for _, o := range []*model.Order{valid, quarantined} {
+		_, err := repo.Store(ctx, o)
+		require.NoError(t, err)
+	}
+
+	srv := miniredis.RunT(t)
+	cache := &redisCache{
+		client:    redis.NewClient(&redis.Options{Addr: srv.Addr()}),
+		source:    repo,
+		namespace: "orders:test:",
+		ttl:       time.Minute,
+	}
+	cache.warmUp.Count = 100
+
+	// Запись, сделанная работающим сервисом до прогрева
+	newer := *valid
+	newer.TrackNumber = "WBILMNEWER"
+	require.NoError(t, cache.Cache(ctx, &newer))
+
+	require.NoError(t, cache.WarmUp(ctx))
+
+	assert.Equal(t, []string{"orders:test:" + valid.OrderUID.String()}, srv.Keys())
+	got, err := cache.GetOrderFromCache(ctx, valid.OrderUID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "WBILMNEWER", got.TrackNumber)
+}
@@ -42,6 +42,12 @@ type Payment struct {
 	DeliveryCost int       `json:"delivery_cost" db:"delivery_cost"`
 	GoodsTotal   int       `json:"goods_total" db:"goods_total"`
 	CustomFee    int       `json:"custom_fee" db:"custom_fee"`
+
+	// Суммы в основных единицах валюты, только в ответах
+	AmountFormatted       string `json:"amount_formatted,omitempty" readonly:"true" example:"18.17 USD"`
+	DeliveryCostFormatted string `json:"delivery_cost_formatted,omitempty" readonly:"true" example:"15.00 USD"`
+	GoodsTotalFormatted   string `json:"goods_total_formatted,omitempty" readonly:"true" example:"3.17 USD"`
+	CustomFeeFormatted    string `json:"custom_fee_formatted,omitempty" readonly:"true" example:"0.00 USD"`
 }
 
 type Item struct {
@@ -58,4 +64,8 @@ type Item struct {
 	NmID        int    `json:"nm_id" db:"nm_id"`
 	Brand       string `json:"brand" db:"brand"`
 	Status      int    `json:"status" db:"status"`
+
+	// Цены в основных единицах валюты, только в ответах
+	PriceFormatted      string `json:"price_formatted,omitempty" readonly:"true" example:"4.53 USD"`
+	TotalPriceFormatted string `json:"total_price_formatted,omitempty" readonly:"true" example:"3.17 USD"`
 }
@@ -1,5 +1,53 @@
 package dto
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+type FieldError struct {
+	Field   string `json:"field" example:"payment.amount"`
+	Rule    string `json:"rule" example:"payment_amount"`
+	Message string `json:"message"`
+}
+
+type ValidationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
+
+// OrderVersion — версия заказа из истории изменений
+type OrderVersion struct {
+	Version   int             `json:"version" example:"2"`
+	Change    string          `json:"change" example:"updated"`
+	Source    string          `json:"source" example:"kafka"`
+	SourceID  string          `json:"source_id,omitempty"`
+	ChangedAt time.Time       `json:"changed_at" format:"date-time"`
+	Order     json.RawMessage `json:"order" swaggertype:"object"`
+}
+
+type OrderHistoryResponse struct {
+	OrderUID string         `json:"order_uid" format:"uuid"`
+	Versions []OrderVersion `json:"versions"`
+}
+
+// OrderSearchHit — найденный заказ, rank — релевантность совпадения
+type OrderSearchHit struct {
+	OrderUID     string    `json:"order_uid" format:"uuid"`
+	TrackNumber  string    `json:"track_number"`
+	CustomerID   string    `json:"customer_id"`
+	DateCreated  time.Time `json:"date_created" format:"date-time"`
+	DeliveryName string    `json:"delivery_name"`
+	DeliveryCity string    `json:"delivery_city" example:"Haifa"`
+	Rank         float64   `json:"rank" example:"0.4"`
+}
+
+// OrderSearchResponse — страница результатов, total — число совпадений всего
+type OrderSearchResponse struct {
+	Total int              `json:"total" example:"1"`
+	Hits  []OrderSearchHit `json:"hits"`
+}
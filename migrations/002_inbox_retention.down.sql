@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS inbox_archive;
+
+DROP INDEX IF EXISTS idx_inbox_processed_at;
+DROP INDEX IF EXISTS idx_inbox_pending;
+
+ALTER TABLE inbox DROP COLUMN IF EXISTS dead_lettered;
+ALTER TABLE inbox DROP COLUMN IF EXISTS processed_at;
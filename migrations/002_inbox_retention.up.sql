@@ -0,0 +1,19 @@
+-- Processing metadata for inbox retention
+ALTER TABLE inbox ADD COLUMN processed_at TIMESTAMP;
+ALTER TABLE inbox ADD COLUMN dead_lettered BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE inbox SET processed_at = created_at WHERE processed;
+
+CREATE INDEX idx_inbox_pending ON inbox(created_at) WHERE NOT processed;
+CREATE INDEX idx_inbox_processed_at ON inbox(processed_at) WHERE processed;
+
+-- Archive for purged inbox messages
+CREATE TABLE IF NOT EXISTS inbox_archive (
+    message_id TEXT PRIMARY KEY,
+    topic TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    dead_lettered BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    processed_at TIMESTAMP,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
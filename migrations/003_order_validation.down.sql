@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_validation_status;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS validation_warnings;
+ALTER TABLE orders DROP COLUMN IF EXISTS validation_status;
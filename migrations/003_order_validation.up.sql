@@ -0,0 +1,5 @@
+-- Validation outcome of accepted orders
+ALTER TABLE orders ADD COLUMN validation_status VARCHAR(20) NOT NULL DEFAULT 'valid';
+ALTER TABLE orders ADD COLUMN validation_warnings JSONB;
+
+CREATE INDEX idx_orders_validation_status ON orders(validation_status) WHERE validation_status <> 'valid';
@@ -0,0 +1,12 @@
+-- Rows whose transaction differs from order_uid can't satisfy the old FK
+DELETE FROM payment WHERE transaction <> order_uid;
+
+ALTER TABLE payment DROP CONSTRAINT payment_transaction_key;
+ALTER TABLE payment DROP CONSTRAINT payment_order_uid_fkey;
+ALTER TABLE payment DROP CONSTRAINT payment_pkey;
+
+ALTER TABLE payment ADD CONSTRAINT payment_pkey PRIMARY KEY (transaction);
+ALTER TABLE payment ADD CONSTRAINT payment_transaction_fkey
+    FOREIGN KEY (transaction) REFERENCES orders(order_uid) ON DELETE CASCADE;
+
+ALTER TABLE payment DROP COLUMN order_uid;
@@ -0,0 +1,15 @@
+-- Payment gets its own FK to orders; transaction becomes an independent unique value
+ALTER TABLE payment ADD COLUMN order_uid VARCHAR(255);
+
+-- Backfill: until now transaction always equalled order_uid
+UPDATE payment SET order_uid = transaction;
+
+ALTER TABLE payment ALTER COLUMN order_uid SET NOT NULL;
+
+ALTER TABLE payment DROP CONSTRAINT payment_transaction_fkey;
+ALTER TABLE payment DROP CONSTRAINT payment_pkey;
+
+ALTER TABLE payment ADD CONSTRAINT payment_pkey PRIMARY KEY (order_uid);
+ALTER TABLE payment ADD CONSTRAINT payment_order_uid_fkey
+    FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE payment ADD CONSTRAINT payment_transaction_key UNIQUE (transaction);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_items_order_uid_rid;
+
+INSERT INTO items SELECT * FROM items_duplicates_backup;
+DROP TABLE items_duplicates_backup;
@@ -0,0 +1,25 @@
+-- rid is the natural key of an item within an order (used by upsert).
+-- Rows that would break the unique index are moved to items_duplicates_backup
+-- (the row with the lowest id stays in items), so nothing is lost silently
+-- and the down migration can put them back.
+CREATE TABLE items_duplicates_backup (LIKE items INCLUDING DEFAULTS);
+
+WITH duplicates AS (
+    DELETE FROM items a
+    USING items b
+    WHERE a.order_uid = b.order_uid AND a.rid = b.rid AND a.id > b.id
+    RETURNING a.*
+)
+INSERT INTO items_duplicates_backup SELECT * FROM duplicates;
+
+DO $$
+DECLARE
+    moved BIGINT;
+BEGIN
+    SELECT count(*) INTO moved FROM items_duplicates_backup;
+    IF moved > 0 THEN
+        RAISE WARNING 'moved % duplicate item rows to items_duplicates_backup', moved;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX idx_items_order_uid_rid ON items(order_uid, rid);
@@ -0,0 +1,74 @@
+-- Back to plain orders/items tables, including archived partitions
+ALTER TABLE delivery DROP CONSTRAINT delivery_order_uid_fkey;
+ALTER TABLE payment DROP CONSTRAINT payment_order_uid_fkey;
+
+CREATE TABLE orders_plain (
+    order_uid VARCHAR(255) PRIMARY KEY,
+    track_number VARCHAR(255) NOT NULL,
+    entry VARCHAR(50) NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    internal_signature VARCHAR(255),
+    customer_id VARCHAR(255) NOT NULL,
+    delivery_service VARCHAR(255) NOT NULL,
+    shardkey VARCHAR(50) NOT NULL,
+    sm_id INTEGER NOT NULL,
+    date_created TIMESTAMP WITH TIME ZONE NOT NULL,
+    oof_shard VARCHAR(50) NOT NULL,
+    validation_status VARCHAR(20) NOT NULL DEFAULT 'valid',
+    validation_warnings JSONB
+);
+
+INSERT INTO orders_plain SELECT
+    order_uid, track_number, entry, locale, internal_signature, customer_id,
+    delivery_service, shardkey, sm_id, date_created, oof_shard,
+    validation_status, validation_warnings
+FROM (SELECT * FROM orders UNION ALL SELECT * FROM orders_archive) o;
+
+CREATE TABLE items_plain (
+    id SERIAL PRIMARY KEY,
+    order_uid VARCHAR(255) REFERENCES orders_plain(order_uid) ON DELETE CASCADE,
+    chrt_id BIGINT NOT NULL,
+    track_number VARCHAR(255) NOT NULL,
+    price INTEGER NOT NULL,
+    rid VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    sale INTEGER NOT NULL,
+    size VARCHAR(50) NOT NULL,
+    total_price INTEGER NOT NULL,
+    nm_id BIGINT NOT NULL,
+    brand VARCHAR(255) NOT NULL,
+    status INTEGER NOT NULL
+);
+
+INSERT INTO items_plain (
+    id, order_uid, chrt_id, track_number, price, rid, name,
+    sale, size, total_price, nm_id, brand, status
+)
+SELECT
+    id, order_uid, chrt_id, track_number, price, rid, name,
+    sale, size, total_price, nm_id, brand, status
+FROM (SELECT * FROM items UNION ALL SELECT * FROM items_archive) i;
+
+SELECT setval(pg_get_serial_sequence('items_plain', 'id'), COALESCE((SELECT MAX(id) FROM items_plain), 0) + 1, false);
+
+DROP TABLE items_archive;
+DROP TABLE orders_archive;
+DROP TABLE items;
+DROP TABLE orders;
+DROP TABLE order_keys;
+
+ALTER TABLE orders_plain RENAME TO orders;
+ALTER INDEX orders_plain_pkey RENAME TO orders_pkey;
+ALTER TABLE items_plain RENAME TO items;
+ALTER INDEX items_plain_pkey RENAME TO items_pkey;
+ALTER SEQUENCE items_plain_id_seq RENAME TO items_id_seq;
+ALTER TABLE items RENAME CONSTRAINT items_plain_order_uid_fkey TO items_order_uid_fkey;
+
+CREATE INDEX idx_items_order_uid ON items(order_uid);
+CREATE UNIQUE INDEX idx_items_order_uid_rid ON items(order_uid, rid);
+CREATE INDEX idx_orders_validation_status ON orders(validation_status) WHERE validation_status <> 'valid';
+
+ALTER TABLE delivery ADD CONSTRAINT delivery_order_uid_fkey
+    FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE payment ADD CONSTRAINT payment_order_uid_fkey
+    FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
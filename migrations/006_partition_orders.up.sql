@@ -0,0 +1,131 @@
+-- Range-partition orders and items by date_created month.
+--
+-- A partitioned table can't have a unique constraint without the partition key,
+-- so order_uid uniqueness moves to order_keys. It is also the FK target for
+-- delivery, payment and items, and lets reads prune to a single partition.
+CREATE TABLE order_keys (
+    order_uid VARCHAR(255) PRIMARY KEY,
+    date_created TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO order_keys (order_uid, date_created)
+SELECT order_uid, date_created FROM orders;
+
+ALTER TABLE delivery DROP CONSTRAINT delivery_order_uid_fkey;
+ALTER TABLE payment DROP CONSTRAINT payment_order_uid_fkey;
+ALTER TABLE items DROP CONSTRAINT items_order_uid_fkey;
+
+ALTER TABLE orders RENAME TO orders_old;
+ALTER INDEX orders_pkey RENAME TO orders_old_pkey;
+ALTER INDEX idx_orders_validation_status RENAME TO idx_orders_old_validation_status;
+
+ALTER TABLE items RENAME TO items_old;
+ALTER INDEX items_pkey RENAME TO items_old_pkey;
+ALTER INDEX idx_items_order_uid RENAME TO idx_items_old_order_uid;
+ALTER INDEX idx_items_order_uid_rid RENAME TO idx_items_old_order_uid_rid;
+
+CREATE TABLE orders (
+    order_uid VARCHAR(255) NOT NULL REFERENCES order_keys(order_uid) ON DELETE CASCADE,
+    track_number VARCHAR(255) NOT NULL,
+    entry VARCHAR(50) NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    internal_signature VARCHAR(255),
+    customer_id VARCHAR(255) NOT NULL,
+    delivery_service VARCHAR(255) NOT NULL,
+    shardkey VARCHAR(50) NOT NULL,
+    sm_id INTEGER NOT NULL,
+    date_created TIMESTAMP WITH TIME ZONE NOT NULL,
+    oof_shard VARCHAR(50) NOT NULL,
+    validation_status VARCHAR(20) NOT NULL DEFAULT 'valid',
+    validation_warnings JSONB,
+    PRIMARY KEY (order_uid, date_created)
+) PARTITION BY RANGE (date_created);
+
+CREATE INDEX idx_orders_validation_status ON orders(validation_status) WHERE validation_status <> 'valid';
+
+CREATE TABLE items (
+    id SERIAL,
+    order_uid VARCHAR(255) NOT NULL REFERENCES order_keys(order_uid) ON DELETE CASCADE,
+    date_created TIMESTAMP WITH TIME ZONE NOT NULL,
+    chrt_id BIGINT NOT NULL,
+    track_number VARCHAR(255) NOT NULL,
+    price INTEGER NOT NULL,
+    rid VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    sale INTEGER NOT NULL,
+    size VARCHAR(50) NOT NULL,
+    total_price INTEGER NOT NULL,
+    nm_id BIGINT NOT NULL,
+    brand VARCHAR(255) NOT NULL,
+    status INTEGER NOT NULL,
+    PRIMARY KEY (id, date_created)
+) PARTITION BY RANGE (date_created);
+
+CREATE INDEX idx_items_order_uid ON items(order_uid);
+CREATE UNIQUE INDEX idx_items_order_uid_rid ON items(order_uid, rid, date_created);
+
+-- Rows outside any monthly partition
+CREATE TABLE orders_default PARTITION OF orders DEFAULT;
+CREATE TABLE items_default PARTITION OF items DEFAULT;
+
+-- Archived monthly partitions are detached from orders/items and attached here
+CREATE TABLE orders_archive (LIKE orders INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+    PARTITION BY RANGE (date_created);
+ALTER TABLE orders_archive ADD PRIMARY KEY (order_uid, date_created);
+
+CREATE TABLE items_archive (LIKE items INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+    PARTITION BY RANGE (date_created);
+ALTER TABLE items_archive ADD PRIMARY KEY (id, date_created);
+CREATE INDEX idx_items_archive_order_uid ON items_archive(order_uid);
+
+-- Monthly partitions (UTC) from the oldest order up to three months ahead
+DO $$
+DECLARE
+    month_start TIMESTAMP;
+    first_month TIMESTAMP;
+BEGIN
+    SELECT date_trunc('month', COALESCE(MIN(date_created), NOW()) AT TIME ZONE 'UTC')
+    INTO first_month
+    FROM orders_old;
+
+    FOR month_start IN
+        SELECT generate_series(first_month, date_trunc('month', NOW() AT TIME ZONE 'UTC') + INTERVAL '3 months', INTERVAL '1 month')
+    LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF orders FOR VALUES FROM (%L) TO (%L)',
+            'orders_' || to_char(month_start, '"y"YYYY"m"MM'),
+            month_start::TEXT || '+00', (month_start + INTERVAL '1 month')::TEXT || '+00'
+        );
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF items FOR VALUES FROM (%L) TO (%L)',
+            'items_' || to_char(month_start, '"y"YYYY"m"MM'),
+            month_start::TEXT || '+00', (month_start + INTERVAL '1 month')::TEXT || '+00'
+        );
+    END LOOP;
+END $$;
+
+INSERT INTO orders SELECT
+    order_uid, track_number, entry, locale, internal_signature, customer_id,
+    delivery_service, shardkey, sm_id, date_created, oof_shard,
+    validation_status, validation_warnings
+FROM orders_old;
+
+INSERT INTO items (
+    id, order_uid, date_created, chrt_id, track_number, price, rid, name,
+    sale, size, total_price, nm_id, brand, status
+)
+SELECT
+    i.id, i.order_uid, o.date_created, i.chrt_id, i.track_number, i.price, i.rid, i.name,
+    i.sale, i.size, i.total_price, i.nm_id, i.brand, i.status
+FROM items_old i
+JOIN orders_old o ON o.order_uid = i.order_uid;
+
+SELECT setval(pg_get_serial_sequence('items', 'id'), COALESCE((SELECT MAX(id) FROM items_old), 0) + 1, false);
+
+DROP TABLE items_old;
+DROP TABLE orders_old;
+
+ALTER TABLE delivery ADD CONSTRAINT delivery_order_uid_fkey
+    FOREIGN KEY (order_uid) REFERENCES order_keys(order_uid) ON DELETE CASCADE;
+ALTER TABLE payment ADD CONSTRAINT payment_order_uid_fkey
+    FOREIGN KEY (order_uid) REFERENCES order_keys(order_uid) ON DELETE CASCADE;
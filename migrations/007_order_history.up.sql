@@ -0,0 +1,55 @@
+-- Snapshot of an order (public JSON format) after every write through the repository
+CREATE TABLE order_history (
+    order_uid VARCHAR(255) NOT NULL REFERENCES order_keys(order_uid) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    change VARCHAR(20) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    source_id VARCHAR(255),
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    snapshot JSONB NOT NULL,
+    PRIMARY KEY (order_uid, version)
+);
+
+CREATE INDEX idx_order_history_changed_at ON order_history(order_uid, changed_at);
+
+-- Existing orders start their history with the current state
+INSERT INTO order_history (order_uid, version, change, source, changed_at, snapshot)
+SELECT
+    o.order_uid, 1, 'created', 'unknown', o.date_created,
+    jsonb_build_object(
+        'order_uid', o.order_uid,
+        'track_number', o.track_number,
+        'entry', o.entry,
+        'delivery', jsonb_build_object(
+            'name', d.name, 'phone', d.phone, 'zip', d.zip, 'city', d.city,
+            'address', d.address, 'region', d.region, 'email', d.email
+        ),
+        'payment', jsonb_build_object(
+            'transaction', p.transaction, 'request_id', COALESCE(p.request_id, ''),
+            'currency', p.currency, 'provider', p.provider, 'amount', p.amount,
+            'payment_dt', EXTRACT(EPOCH FROM p.payment_dt)::BIGINT, 'bank', p.bank,
+            'delivery_cost', p.delivery_cost, 'goods_total', p.goods_total,
+            'custom_fee', p.custom_fee
+        ),
+        'items', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'chrt_id', i.chrt_id, 'track_number', i.track_number, 'price', i.price,
+                'rid', i.rid, 'name', i.name, 'sale', i.sale, 'size', i.size,
+                'total_price', i.total_price, 'nm_id', i.nm_id, 'brand', i.brand,
+                'status', i.status
+            ) ORDER BY i.id)
+            FROM (SELECT * FROM items UNION ALL SELECT * FROM items_archive) i
+            WHERE i.order_uid = o.order_uid
+        ), '[]'::jsonb),
+        'locale', o.locale,
+        'internal_signature', COALESCE(o.internal_signature, ''),
+        'customer_id', o.customer_id,
+        'delivery_service', o.delivery_service,
+        'shardkey', o.shardkey,
+        'sm_id', o.sm_id,
+        'date_created', to_char(o.date_created AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+        'oof_shard', o.oof_shard
+    )
+FROM (SELECT * FROM orders UNION ALL SELECT * FROM orders_archive) o
+JOIN delivery d ON d.order_uid = o.order_uid
+JOIN payment p ON p.order_uid = o.order_uid;
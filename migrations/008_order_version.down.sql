@@ -0,0 +1,2 @@
+ALTER TABLE orders_archive DROP COLUMN version;
+ALTER TABLE orders DROP COLUMN version;
@@ -0,0 +1,3 @@
+-- Optimistic concurrency: version is incremented on every write of an order
+ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE orders_archive ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
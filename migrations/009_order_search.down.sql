@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS trg_delivery_order_search ON delivery;
+DROP TRIGGER IF EXISTS trg_items_order_search ON items;
+DROP FUNCTION IF EXISTS order_search_trigger();
+DROP FUNCTION IF EXISTS refresh_order_search(VARCHAR);
+DROP FUNCTION IF EXISTS order_search_document(VARCHAR);
+DROP TABLE IF EXISTS order_search;
@@ -0,0 +1,56 @@
+-- Full-text search document per order: item names and brands (weight A),
+-- delivery city (weight B). Kept in a separate table because the text spans
+-- items and delivery, and order_keys holds every order, live or archived.
+CREATE TABLE order_search (
+    order_uid VARCHAR(255) PRIMARY KEY REFERENCES order_keys(order_uid) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX idx_order_search_document ON order_search USING GIN (document);
+
+CREATE FUNCTION order_search_document(uid VARCHAR) RETURNS TSVECTOR AS $$
+    SELECT
+        setweight(to_tsvector('english', COALESCE((
+            SELECT string_agg(i.name || ' ' || i.brand, ' ')
+            FROM (
+                SELECT name, brand FROM items WHERE order_uid = uid
+                UNION ALL
+                SELECT name, brand FROM items_archive WHERE order_uid = uid
+            ) i
+        ), '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE((
+            SELECT city FROM delivery WHERE order_uid = uid
+        ), '')), 'B')
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION refresh_order_search(uid VARCHAR) RETURNS VOID AS $$
+    -- The order may be gone already when its rows are removed by cascade
+    INSERT INTO order_search (order_uid, document)
+    SELECT uid, order_search_document(uid)
+    WHERE EXISTS (SELECT 1 FROM order_keys WHERE order_uid = uid)
+    ON CONFLICT (order_uid) DO UPDATE SET document = EXCLUDED.document
+$$ LANGUAGE sql;
+
+CREATE FUNCTION order_search_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_order_search(OLD.order_uid);
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.order_uid IS DISTINCT FROM OLD.order_uid) THEN
+        PERFORM refresh_order_search(NEW.order_uid);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_items_order_search
+    AFTER INSERT OR DELETE OR UPDATE OF order_uid, name, brand ON items
+    FOR EACH ROW EXECUTE FUNCTION order_search_trigger();
+
+CREATE TRIGGER trg_delivery_order_search
+    AFTER INSERT OR DELETE OR UPDATE OF order_uid, city ON delivery
+    FOR EACH ROW EXECUTE FUNCTION order_search_trigger();
+
+INSERT INTO order_search (order_uid, document)
+SELECT order_uid, order_search_document(order_uid)
+FROM order_keys;
@@ -0,0 +1,2 @@
+ALTER TABLE inbox_archive DROP COLUMN message_key;
+ALTER TABLE inbox DROP COLUMN message_key;
@@ -0,0 +1,8 @@
+-- message_id is now unique per Kafka message (topic/partition/offset);
+-- the Kafka key (order_uid) is kept separately for per-key ordering
+ALTER TABLE inbox ADD COLUMN message_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE inbox_archive ADD COLUMN message_key TEXT NOT NULL DEFAULT '';
+
+-- Before this migration message_id held the Kafka key
+UPDATE inbox SET message_key = message_id;
+UPDATE inbox_archive SET message_key = message_id;
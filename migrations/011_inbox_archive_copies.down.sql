@@ -0,0 +1,7 @@
+-- Keep the earliest copy of each message
+DELETE FROM inbox_archive a USING inbox_archive b
+WHERE a.message_id = b.message_id AND a.id > b.id;
+
+DROP INDEX idx_inbox_archive_message_id;
+ALTER TABLE inbox_archive DROP COLUMN id;
+ALTER TABLE inbox_archive ADD PRIMARY KEY (message_id);
@@ -0,0 +1,6 @@
+-- The same message_id can be archived more than once (the message is
+-- consumed again after its first copy was purged), so every copy gets its
+-- own key instead of the first one silently winning
+ALTER TABLE inbox_archive DROP CONSTRAINT inbox_archive_pkey;
+ALTER TABLE inbox_archive ADD COLUMN id BIGSERIAL PRIMARY KEY;
+CREATE INDEX idx_inbox_archive_message_id ON inbox_archive(message_id);
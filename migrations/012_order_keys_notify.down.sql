@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS order_keys_notify ON order_keys;
+DROP FUNCTION IF EXISTS notify_order_key();
@@ -0,0 +1,13 @@
+-- Announce every new order so that the in-memory existence filters of all
+-- service instances learn about it without waiting for a full rescan.
+-- NOTIFY is delivered on commit, so listeners never see rolled-back orders.
+CREATE FUNCTION notify_order_key() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('order_keys_new', NEW.order_uid);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER order_keys_notify
+    AFTER INSERT ON order_keys
+    FOR EACH ROW EXECUTE FUNCTION notify_order_key();
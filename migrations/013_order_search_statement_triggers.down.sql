@@ -0,0 +1,28 @@
+DROP TRIGGER IF EXISTS trg_delivery_order_search_delete ON delivery;
+DROP TRIGGER IF EXISTS trg_delivery_order_search_update ON delivery;
+DROP TRIGGER IF EXISTS trg_delivery_order_search_insert ON delivery;
+DROP TRIGGER IF EXISTS trg_items_order_search_delete ON items;
+DROP TRIGGER IF EXISTS trg_items_order_search_update ON items;
+DROP TRIGGER IF EXISTS trg_items_order_search_insert ON items;
+DROP FUNCTION IF EXISTS delivery_order_search_trigger();
+DROP FUNCTION IF EXISTS items_order_search_trigger();
+
+CREATE FUNCTION order_search_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_order_search(OLD.order_uid);
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.order_uid IS DISTINCT FROM OLD.order_uid) THEN
+        PERFORM refresh_order_search(NEW.order_uid);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_items_order_search
+    AFTER INSERT OR DELETE OR UPDATE OF order_uid, name, brand ON items
+    FOR EACH ROW EXECUTE FUNCTION order_search_trigger();
+
+CREATE TRIGGER trg_delivery_order_search
+    AFTER INSERT OR DELETE OR UPDATE OF order_uid, city ON delivery
+    FOR EACH ROW EXECUTE FUNCTION order_search_trigger();
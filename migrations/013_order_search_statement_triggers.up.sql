@@ -0,0 +1,92 @@
+-- Refresh order_search once per statement instead of once per changed row.
+-- The row-level triggers rebuilt the whole order document for every item
+-- row, so storing an order with n items cost O(n^2). Store writes all items
+-- of an order in one statement, and the statement-level triggers below
+-- refresh every affected order once, using the transition tables.
+-- A trigger with transition tables fires for a single event and cannot have
+-- a column list, so the UPDATE branch compares the indexed columns itself.
+DROP TRIGGER trg_items_order_search ON items;
+DROP TRIGGER trg_delivery_order_search ON delivery;
+DROP FUNCTION order_search_trigger();
+
+CREATE FUNCTION items_order_search_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM refresh_order_search(uid)
+        FROM (SELECT DISTINCT order_uid FROM new_rows) changed(uid);
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM refresh_order_search(uid)
+        FROM (SELECT DISTINCT order_uid FROM old_rows) changed(uid);
+    ELSE
+        PERFORM refresh_order_search(uid)
+        FROM (
+            SELECT DISTINCT order_uid FROM (
+                (SELECT order_uid, name, brand FROM new_rows
+                 EXCEPT ALL
+                 SELECT order_uid, name, brand FROM old_rows)
+                UNION ALL
+                (SELECT order_uid, name, brand FROM old_rows
+                 EXCEPT ALL
+                 SELECT order_uid, name, brand FROM new_rows)
+            ) diff
+        ) changed(uid);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION delivery_order_search_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM refresh_order_search(uid)
+        FROM (SELECT DISTINCT order_uid FROM new_rows) changed(uid);
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM refresh_order_search(uid)
+        FROM (SELECT DISTINCT order_uid FROM old_rows) changed(uid);
+    ELSE
+        PERFORM refresh_order_search(uid)
+        FROM (
+            SELECT DISTINCT order_uid FROM (
+                (SELECT order_uid, city FROM new_rows
+                 EXCEPT ALL
+                 SELECT order_uid, city FROM old_rows)
+                UNION ALL
+                (SELECT order_uid, city FROM old_rows
+                 EXCEPT ALL
+                 SELECT order_uid, city FROM new_rows)
+            ) diff
+        ) changed(uid);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_items_order_search_insert
+    AFTER INSERT ON items
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION items_order_search_trigger();
+
+CREATE TRIGGER trg_items_order_search_update
+    AFTER UPDATE ON items
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION items_order_search_trigger();
+
+CREATE TRIGGER trg_items_order_search_delete
+    AFTER DELETE ON items
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION items_order_search_trigger();
+
+CREATE TRIGGER trg_delivery_order_search_insert
+    AFTER INSERT ON delivery
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION delivery_order_search_trigger();
+
+CREATE TRIGGER trg_delivery_order_search_update
+    AFTER UPDATE ON delivery
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION delivery_order_search_trigger();
+
+CREATE TRIGGER trg_delivery_order_search_delete
+    AFTER DELETE ON delivery
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION delivery_order_search_trigger();
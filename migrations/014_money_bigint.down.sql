@@ -0,0 +1,14 @@
+-- Fails with 22003 if some amount no longer fits into INTEGER
+ALTER TABLE items_archive
+    ALTER COLUMN price TYPE INTEGER,
+    ALTER COLUMN total_price TYPE INTEGER;
+
+ALTER TABLE items
+    ALTER COLUMN price TYPE INTEGER,
+    ALTER COLUMN total_price TYPE INTEGER;
+
+ALTER TABLE payment
+    ALTER COLUMN amount TYPE INTEGER,
+    ALTER COLUMN delivery_cost TYPE INTEGER,
+    ALTER COLUMN goods_total TYPE INTEGER,
+    ALTER COLUMN custom_fee TYPE INTEGER;
@@ -0,0 +1,17 @@
+-- Money is stored in minor units as int64 (model.Money), but the columns were
+-- INTEGER: amounts above 2^31-1 passed validation and then failed with 22003.
+-- Altering a partitioned table also alters its partitions; items_archive has
+-- to be altered separately, since archived partitions are attached to it.
+ALTER TABLE payment
+    ALTER COLUMN amount TYPE BIGINT,
+    ALTER COLUMN delivery_cost TYPE BIGINT,
+    ALTER COLUMN goods_total TYPE BIGINT,
+    ALTER COLUMN custom_fee TYPE BIGINT;
+
+ALTER TABLE items
+    ALTER COLUMN price TYPE BIGINT,
+    ALTER COLUMN total_price TYPE BIGINT;
+
+ALTER TABLE items_archive
+    ALTER COLUMN price TYPE BIGINT,
+    ALTER COLUMN total_price TYPE BIGINT;
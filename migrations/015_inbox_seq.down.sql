@@ -0,0 +1,5 @@
+DROP INDEX idx_inbox_pending;
+CREATE INDEX idx_inbox_pending ON inbox(created_at) WHERE NOT processed;
+
+-- Drops the owned sequence as well
+ALTER TABLE inbox DROP COLUMN seq;
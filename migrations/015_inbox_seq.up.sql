@@ -0,0 +1,24 @@
+-- Inbox messages are processed in arrival order. created_at comes from the
+-- service clock and can repeat, so ties were broken arbitrarily; seq is a
+-- strictly increasing arrival number. Existing rows are numbered by
+-- created_at, ties by message_id.
+CREATE SEQUENCE inbox_seq_seq;
+
+ALTER TABLE inbox ADD COLUMN seq BIGINT;
+
+UPDATE inbox i SET seq = o.n
+FROM (
+    SELECT message_id, row_number() OVER (ORDER BY created_at, message_id) AS n
+    FROM inbox
+) o
+WHERE i.message_id = o.message_id;
+
+SELECT setval('inbox_seq_seq', COALESCE(MAX(seq), 0) + 1, false) FROM inbox;
+
+ALTER TABLE inbox
+    ALTER COLUMN seq SET DEFAULT nextval('inbox_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+ALTER SEQUENCE inbox_seq_seq OWNED BY inbox.seq;
+
+DROP INDEX idx_inbox_pending;
+CREATE INDEX idx_inbox_pending ON inbox(seq) WHERE NOT processed;
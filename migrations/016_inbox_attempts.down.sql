@@ -0,0 +1,5 @@
+ALTER TABLE inbox_archive DROP COLUMN last_error;
+ALTER TABLE inbox_archive DROP COLUMN attempts;
+
+ALTER TABLE inbox DROP COLUMN last_error;
+ALTER TABLE inbox DROP COLUMN attempts;
@@ -0,0 +1,8 @@
+-- Failed processing attempts of an inbox message. A message that keeps
+-- failing is dead-lettered after inbox.max_attempts instead of blocking the
+-- messages behind it forever.
+ALTER TABLE inbox ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE inbox ADD COLUMN last_error TEXT;
+
+ALTER TABLE inbox_archive ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE inbox_archive ADD COLUMN last_error TEXT;
@@ -0,0 +1,9 @@
+// Package migrations встраивает SQL-миграции схемы в бинарник
+package migrations
+
+import "embed"
+
+// FS — файлы NNN_name.up.sql и NNN_name.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
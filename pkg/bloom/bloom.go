@@ -0,0 +1,74 @@
+// Package bloom — фильтр Блума для строковых ключей
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// Filter отвечает на вопрос "мог ли ключ быть добавлен". false — ключа точно
+// не было, true — был или ложное срабатывание. Безопасен для одновременного
+// использования.
+type Filter struct {
+	bits   []uint64
+	m      uint64 // число бит
+	k      uint64 // число хэш-функций
+	length atomic.Int64
+}
+
+// New создаёт фильтр на n ключей с долей ложных срабатываний p
+func New(n int, p float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max((m+63)/64*64, 64)
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = max(k, 1)
+
+	return &Filter{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+func (f *Filter) Add(key string) {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		atomic.OrUint64(&f.bits[bit/64], 1<<(bit%64))
+	}
+	f.length.Add(1)
+}
+
+func (f *Filter) MayContain(key string) bool {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if atomic.LoadUint64(&f.bits[bit/64])&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Len — сколько раз вызывался Add (повторы считаются)
+func (f *Filter) Len() int {
+	return int(f.length.Load())
+}
+
+// hashes — две независимые половины FNV-128a для двойного хэширования
+func hashes(key string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(key))
+	sum := h.Sum(nil)
+
+	var h1, h2 uint64
+	for i := 0; i < 8; i++ {
+		h1 = h1<<8 | uint64(sum[i])
+		h2 = h2<<8 | uint64(sum[i+8])
+	}
+	return h1, h2 | 1
+}
@@ -0,0 +1,40 @@
+package bloom
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterHasNoFalseNegatives(t *testing.T) {
+	f := New(1000, 0.01)
+
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = uuid.NewString()
+		f.Add(keys[i])
+	}
+
+	for _, key := range keys {
+		assert.True(t, f.MayContain(key))
+	}
+	assert.Equal(t, 1000, f.Len())
+}
+
+func TestFilterFalsePositiveRate(t *testing.T) {
+	f := New(10000, 0.01)
+	for range 10000 {
+		f.Add(uuid.NewString())
+	}
+
+	falsePositives := 0
+	const probes = 10000
+	for range probes {
+		if f.MayContain(uuid.NewString()) {
+			falsePositives++
+		}
+	}
+	// Ожидается около 1%, запас на случайность
+	assert.Less(t, float64(falsePositives)/probes, 0.03)
+}